@@ -2,42 +2,169 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"Factory/internal/util"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/puddle/v2"
 )
 
+// Violation describes a single failed validation rule of a request
+type Violation struct {
+	Location  string `json:"location"`
+	Parameter string `json:"parameter"`
+	Value     string `json:"value,omitempty"`
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+}
+
 type response struct {
 	Id      string
 	Code    int
 	Message string
+	Errors  []Violation `json:",omitempty"`
+}
+
+// problem is an RFC 7807 problem details object, the violations
+// of a failed validation being carried as an extension member
+type problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail"`
+	Instance string      `json:"instance"`
+	Errors   []Violation `json:"errors,omitempty"`
+}
+
+// quality returns the weight the Accept header gives to a media type
+func quality(accept string, media string) float64 {
+	for _, entry := range strings.Split(accept, ",") {
+		params := strings.Split(entry, ";")
+		if strings.TrimSpace(params[0]) != media {
+			continue
+		}
+
+		for _, param := range params[1:] {
+			if q, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				weight, _ := strconv.ParseFloat(q, 64)
+				return weight
+			}
+		}
+		return 1
+	}
+	return 0
+}
+
+// legacy reports whether the client prefers plain JSON over problem details
+func legacy(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return quality(accept, "application/json") > quality(accept, "application/problem+json")
 }
 
-func raise(w http.ResponseWriter, r *http.Request, code int, err string) {
+func raise(w http.ResponseWriter, r *http.Request, code int, err string, violations ...Violation) {
 	id := w.Header().Get("X-Correlation-ID")
 	util.GetLogger(r).Error(err)
 
-	w.WriteHeader(code)
-	response := response{
-		Id:      id,
-		Code:    code,
-		Message: err,
+	if legacy(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(response{
+			Id:      id,
+			Code:    code,
+			Message: err,
+			Errors:  violations,
+		})
+		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(code),
+		Status:   code,
+		Detail:   err,
+		Instance: id,
+		Errors:   violations,
+	})
 }
 
 var (
 	RequestErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
 		raise(w, r, http.StatusBadRequest, err)
 	}
+	ValidationErrorHandler = func(w http.ResponseWriter, r *http.Request, violations []Violation) {
+		var messages []string
+		for _, v := range violations {
+			messages = append(messages, v.Location+": "+v.Message)
+		}
+		raise(w, r, http.StatusBadRequest, strings.Join(messages, ". "), violations...)
+	}
 	NotFoundErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
 		raise(w, r, http.StatusNotFound, err)
 	}
+	ConflictErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
+		raise(w, r, http.StatusConflict, err)
+	}
+	TooLargeErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
+		raise(w, r, http.StatusRequestEntityTooLarge, err)
+	}
+	UnprocessableErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
+		raise(w, r, http.StatusUnprocessableEntity, err)
+	}
 	ConnectionErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
 		raise(w, r, http.StatusServiceUnavailable, err)
 	}
+	NotImplementedErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
+		raise(w, r, http.StatusNotImplemented, err)
+	}
+	GatewayErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
+		raise(w, r, http.StatusBadGateway, err)
+	}
+	GatewayTimeoutErrorHandler = func(w http.ResponseWriter, r *http.Request, err string) {
+		raise(w, r, http.StatusGatewayTimeout, err)
+	}
 	InternalErrorHandler = func(w http.ResponseWriter, r *http.Request) {
 		raise(w, r, http.StatusInternalServerError, "Internal Server Error")
 	}
+	DatabaseErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		var pgErr *pgconn.PgError
+		var netErr net.Error
+		var connectErr *pgconn.ConnectError
+
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			ConflictErrorHandler(w, r, detail(pgErr, "the entry already exists"))
+		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+			UnprocessableErrorHandler(w, r, detail(pgErr, "the entry references an entry that does not exist"))
+		case errors.As(err, &pgErr) && unavailable(pgErr.Code),
+			errors.As(err, &connectErr), errors.As(err, &netErr),
+			errors.Is(err, puddle.ErrClosedPool), pgconn.Timeout(err):
+			util.GetLogger(r).Error(err)
+			ConnectionErrorHandler(w, r, "the database is unavailable")
+		default:
+			util.GetLogger(r).Error(err)
+			InternalErrorHandler(w, r)
+		}
+	}
 )
+
+// unavailable reports whether an SQLSTATE means the database cannot serve
+// requests for now: connection exceptions, shutdowns and exhausted resources
+func unavailable(code string) bool {
+	return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "57P") || strings.HasPrefix(code, "53")
+}
+
+// detail describes a constraint violation, preferring the key
+// reported by Postgres over the generic message
+func detail(err *pgconn.PgError, message string) string {
+	if err.Detail != "" {
+		return message + ": " + err.Detail
+	}
+	return message
+}
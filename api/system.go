@@ -14,21 +14,26 @@ import (
 type GetSystemEndpointsMethod struct {
 	Query   int `json:"query,omitempty"`
 	Headers int `json:"headers,omitempty"`
+	Body    int `json:"body,omitempty"`
 }
 
 type GetSystemEndpoints struct {
 	Path       string `json:"path"`
 	UriParams  int    `json:"uriParams,omitempty"`
 	Methods    int    `json:"methods,omitempty"`
+	Table      string `json:"table,omitempty"`
+	Upstream   int    `json:"upstream,omitempty"`
 	Configured any    `json:"configured"`
 }
 
 type GetSystemMethod struct {
-	Id      int    `json:"id"`
-	Method  string `json:"method"`
-	Uri     int    `json:"uriParams"`
-	Query   int    `json:"query"`
-	Headers int    `json:"headers"`
+	Id       int    `json:"id"`
+	Method   string `json:"method"`
+	Uri      int    `json:"uriParams"`
+	Query    int    `json:"query"`
+	Headers  int    `json:"headers"`
+	Body     int    `json:"body"`
+	Examples int    `json:"examples,omitempty"`
 }
 
 type GetSystemParametersById struct {
@@ -37,11 +42,24 @@ type GetSystemParametersById struct {
 	Properties map[string]string `json:"properties,omitempty"`
 }
 
+type GetSystemUpstream struct {
+	Target      string            `json:"target"`
+	Timeout     int               `json:"timeout"`
+	Passthrough []string          `json:"passthrough,omitempty"`
+	Inject      map[string]string `json:"inject,omitempty"`
+}
+
+type GetSystemExample struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
 /*  **************************
           POST REQUESTS
 	************************** */
 
-func SuccessfulSystemPost(w http.ResponseWriter, r *http.Request, args ...string) {
+func success(w http.ResponseWriter, r *http.Request, args ...string) {
 	message := strings.Join(args, " ")
 	util.GetLogger(r).Info(message)
 
@@ -56,6 +74,10 @@ func SuccessfulSystemPost(w http.ResponseWriter, r *http.Request, args ...string
 	})
 }
 
+func SuccessfulSystemPost(w http.ResponseWriter, r *http.Request, args ...string) {
+	success(w, r, args...)
+}
+
 type PostSystemEndpointRequest struct {
 	Path      string
 	Methods   int
@@ -66,4 +88,123 @@ type PostSystemMethodRequest struct {
 	Name    string
 	Query   int
 	Headers int
+	Body    int
+}
+
+type PostSystemExampleRequest struct {
+	Status  int
+	Headers map[string]string
+	Body    string
+}
+
+type PostSystemPropertyRequest struct {
+	Type       string
+	Properties map[string]string
+}
+
+// PostSystemOpenApiReport lists what an OpenAPI import creates, what it
+// had to leave out and what prevents it from being applied
+type PostSystemOpenApiReport struct {
+	DryRun      bool     `json:"dryRun"`
+	Created     []string `json:"created"`
+	Unsupported []string `json:"unsupported,omitempty"`
+	Conflicts   []string `json:"conflicts,omitempty"`
+}
+
+// PostSystemCatalogReport lists the changes between the catalog
+// and a declarative description of it
+type PostSystemCatalogReport struct {
+	Applied bool     `json:"applied"`
+	Changes []string `json:"changes"`
+}
+
+// PostSystemImportReport lists what a snapshot import created and
+// how the endpoints already registered were treated
+type PostSystemImportReport struct {
+	Policy      string   `json:"policy"`
+	Created     []string `json:"created"`
+	Skipped     []string `json:"skipped,omitempty"`
+	Overwritten []string `json:"overwritten,omitempty"`
+}
+
+type PostSystemParameterRequest struct {
+	Name       string
+	Type       string
+	Required   bool
+	Properties int
+}
+
+/*  **************************
+           PUT REQUESTS
+	************************** */
+
+func SuccessfulSystemPut(w http.ResponseWriter, r *http.Request, args ...string) {
+	success(w, r, args...)
+}
+
+type PutSystemEndpointRequest struct {
+	Path      string
+	Methods   int
+	UriParams int
+	Table     string
+}
+
+type PutSystemMethodRequest struct {
+	Query   int
+	Headers int
+	Body    int
+}
+
+type PutSystemExampleRequest struct {
+	Status  int
+	Headers map[string]string
+	Body    string
+}
+
+type PutSystemUpstreamRequest struct {
+	Target      string
+	Timeout     int
+	Passthrough []string
+	Inject      map[string]string
+}
+
+type PutSystemParameterRequest struct {
+	Type       string
+	Required   bool
+	Properties int
+}
+
+/*  **************************
+          PATCH REQUESTS
+	************************** */
+
+func SuccessfulSystemPatch(w http.ResponseWriter, r *http.Request, args ...string) {
+	success(w, r, args...)
+}
+
+type PatchSystemEndpointRequest struct {
+	Path      *string
+	Methods   *int
+	UriParams *int
+	Table     *string
+}
+
+type PatchSystemMethodRequest struct {
+	Query   *int
+	Headers *int
+	Body    *int
+}
+
+type PatchSystemParameterRequest struct {
+	Type       *string
+	Required   *bool
+	Properties *int
+}
+
+/*  **************************
+          DELETE REQUESTS
+	************************** */
+
+func SuccessfulSystemDelete(w http.ResponseWriter, r *http.Request, args ...string) {
+	success(w, r, args...)
 }
@@ -11,26 +11,39 @@ import (
 	"github.com/go-chi/chi"
 )
 
-func catalog(r *chi.Mux) {
+var handlers = map[string]http.Handler{
+	"GET":    http.HandlerFunc(rest.Get),
+	"POST":   http.HandlerFunc(rest.Post),
+	"PUT":    http.HandlerFunc(rest.Put),
+	"DELETE": http.HandlerFunc(rest.Delete),
+	"PATCH":  http.HandlerFunc(rest.Patch),
+}
+
+func catalog(r *chi.Mux, registry _registry) {
 	for _, endpoint := range registry.endpoints {
-		r.Route(endpoint.path, func(r chi.Router) {
-			r.Use(endpoint.validationHandler)
+		r.Route(endpoint.Path, func(r chi.Router) {
+			r.Use(registry.validationHandler(endpoint))
+			if endpoint.Table != "" {
+				r.Use(rest.Bind(endpoint.Table))
+			}
 
-			verbs := maps.Keys(registry.methods[endpoint.methods])
+			verbs := maps.Keys(registry.methods[endpoint.Methods])
 			methods := slices.Collect(verbs)
 			for _, verb := range methods {
-				switch verb {
-				case "GET":
-					r.Get("/", rest.Get)
-				case "POST":
-					r.Post("/", rest.Post)
-				case "PUT":
-					r.Put("/", rest.Put)
-				case "DELETE":
-					r.Delete("/", rest.Delete)
-				case "PATCH":
-					r.Patch("/", rest.Patch)
+				handler, ok := handlers[verb]
+				if !ok {
+					continue
 				}
+
+				// methods carrying examples are mocked until they are removed,
+				// the upstream of the endpoint otherwise taking precedence
+				if m := registry.methods[endpoint.Methods][verb]; m.Examples != 0 {
+					handler = rest.Mock(registry.mocks(m.Examples))
+				} else if u, ok := registry.upstreams[endpoint.Upstream]; ok {
+					handler = proxy(u)
+				}
+
+				r.Method(verb, "/", handler)
 			}
 
 			r.Options("/", func(w http.ResponseWriter, _ *http.Request) {
@@ -0,0 +1,481 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+)
+
+// document is an ID independent description of catalog entries in which
+// parameter and property groups are named and referenced by that name,
+// the schema property of a property group naming a parameter group
+type document struct {
+	Endpoints  map[string]documentEndpoint             `json:"endpoints"`
+	Parameters map[string]map[string]documentParameter `json:"parameters,omitempty"`
+	Properties map[string]map[string]string            `json:"properties,omitempty"`
+}
+
+type documentEndpoint struct {
+	UriParams string                    `json:"uriParams,omitempty"`
+	Table     string                    `json:"table,omitempty"`
+	Upstream  *documentUpstream         `json:"upstream,omitempty"`
+	Methods   map[string]documentMethod `json:"methods,omitempty"`
+}
+
+type documentMethod struct {
+	Query    string                     `json:"query,omitempty"`
+	Headers  string                     `json:"headers,omitempty"`
+	Body     string                     `json:"body,omitempty"`
+	Examples map[string]documentExample `json:"examples,omitempty"`
+}
+
+type documentParameter struct {
+	Type       string `json:"type"`
+	Required   bool   `json:"required,omitempty"`
+	Properties string `json:"properties,omitempty"`
+}
+
+type documentExample struct {
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+type documentUpstream struct {
+	Target      string            `json:"target"`
+	Timeout     int               `json:"timeout,omitempty"`
+	Passthrough []string          `json:"passthrough,omitempty"`
+	Inject      map[string]string `json:"inject,omitempty"`
+}
+
+// maxDocument bounds the size of the documents posted to the admin API
+const maxDocument = 8 << 20
+
+// parseRequest parses the body of a request as the document called kind,
+// answering the request itself when the body is too large or malformed
+func parseRequest(w http.ResponseWriter, r *http.Request, kind string, into any) bool {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDocument))
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		message := "%s exceeds %v bytes"
+		message = util.Message(message, kind, tooLarge.Limit)
+		api.TooLargeErrorHandler(w, r, message)
+		return false
+	}
+
+	if err == nil {
+		err = parseDocument(data, into)
+	}
+	if err != nil {
+		message := "%s could not be parsed: %s"
+		message = util.Message(message, kind, err.Error())
+		api.RequestErrorHandler(w, r, message)
+		return false
+	}
+
+	return true
+}
+
+// parseDocument reads a document written as JSON or YAML
+func parseDocument(data []byte, into any) error {
+	if trimmed := bytes.TrimSpace(data); !bytes.HasPrefix(trimmed, []byte("{")) {
+		value, err := util.ParseYAML(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(into)
+}
+
+// check lists the problems that would prevent installing the document
+func (d document) check(ctx context.Context) []string {
+	var problems []string
+
+	var exists = func(kind, name string, groups map[string]bool) {
+		if name != "" && !groups[name] {
+			problems = append(problems, util.Message("%s group %s is not defined", kind, name))
+		}
+	}
+
+	var parameters = make(map[string]bool)
+	for name, params := range d.Parameters {
+		parameters[name] = len(params) != 0
+	}
+
+	var properties = make(map[string]bool)
+	for name, props := range d.Properties {
+		properties[name] = len(props) != 0
+		exists("parameter", props["schema"], parameters)
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(d.Parameters)) {
+		for _, name := range slices.Sorted(maps.Keys(d.Parameters[group])) {
+			p := d.Parameters[group][name]
+			if !slices.Contains(types, p.Type) {
+				problems = append(problems, util.Message("%s of %s has unknown type %s", name, group, p.Type))
+			}
+
+			// the same rules as the admin API, schemas naming groups of the document
+			exists("property", p.Properties, properties)
+			if err := propertyRules(p.Type, d.Properties[p.Properties]); err != nil {
+				problems = append(problems, util.Message("%s of %s: %s", name, group, err.Error()))
+			}
+		}
+	}
+
+	var routable = true
+	for _, path := range slices.Sorted(maps.Keys(d.Endpoints)) {
+		e := d.Endpoints[path]
+		exists("parameter", e.UriParams, parameters)
+
+		if err := checkRoutes(path); err != nil {
+			problems = append(problems, util.Message("path %s is not valid: %s", path, err.Error()))
+			routable = false
+		}
+
+		if e.Table != "" {
+			if bindable, err := store.bindable(ctx, e.Table); err != nil || !bindable {
+				message := "table %s of %s does not exist or may not be bound"
+				problems = append(problems, util.Message(message, e.Table, path))
+			}
+		}
+
+		for _, verb := range slices.Sorted(maps.Keys(e.Methods)) {
+			if _, ok := handlers[verb]; !ok {
+				problems = append(problems, util.Message("%s of %s is not a supported method", verb, path))
+			}
+
+			m := e.Methods[verb]
+			exists("parameter", m.Query, parameters)
+			exists("parameter", m.Headers, parameters)
+			exists("parameter", m.Body, parameters)
+		}
+	}
+
+	if err := checkRoutes(slices.Collect(maps.Keys(d.Endpoints))...); routable && err != nil {
+		problems = append(problems, util.Message("paths conflict: %s", err.Error()))
+	}
+
+	return problems
+}
+
+// summary lists the entries described by the document
+func (d document) summary() []string {
+	var entries []string
+
+	for _, path := range slices.Sorted(maps.Keys(d.Endpoints)) {
+		entries = append(entries, "endpoint "+path)
+		for _, verb := range slices.Sorted(maps.Keys(d.Endpoints[path].Methods)) {
+			entries = append(entries, "method "+verb+" "+path)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.Parameters)) {
+		names := slices.Sorted(maps.Keys(d.Parameters[name]))
+		entries = append(entries, "parameter group "+name+" ("+strings.Join(names, ", ")+")")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.Properties)) {
+		names := slices.Sorted(maps.Keys(d.Properties[name]))
+		entries = append(entries, "property group "+name+" ("+strings.Join(names, ", ")+")")
+	}
+
+	return entries
+}
+
+// rows holds the registry entries written for a document
+type rows struct {
+	endpoints  []_endpoint
+	methods    []_method
+	parameters []_parameter
+	properties []_property
+	examples   []_example
+	upstreams  []_upstream
+}
+
+// install writes every entry of a checked document within the transaction,
+// group ids being allocated up front as groups may reference one another;
+// endpoints listed in ids are written back under their former id
+func install(tx writer, d document, ids map[string]int) (rows, error) {
+	var written rows
+
+	var parameters = make(map[string]int)
+	for _, name := range slices.Sorted(maps.Keys(d.Parameters)) {
+		if len(d.Parameters[name]) == 0 {
+			continue
+		}
+
+		id, err := tx.allocate("parameter")
+		if err != nil {
+			return written, err
+		}
+		parameters[name] = id
+	}
+
+	var properties = make(map[string]int)
+	for _, name := range slices.Sorted(maps.Keys(d.Properties)) {
+		if len(d.Properties[name]) == 0 {
+			continue
+		}
+
+		id, err := tx.allocate("property")
+		if err != nil {
+			return written, err
+		}
+		properties[name] = id
+
+		for _, key := range slices.Sorted(maps.Keys(d.Properties[name])) {
+			value := d.Properties[name][key]
+			if key == "schema" {
+				value = strconv.Itoa(parameters[value])
+			}
+
+			row := _property{id, key, value}
+			if err = tx.insert(&row); err != nil {
+				return written, err
+			}
+			written.properties = append(written.properties, row)
+		}
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(parameters)) {
+		for _, name := range slices.Sorted(maps.Keys(d.Parameters[group])) {
+			p := d.Parameters[group][name]
+			row := _parameter{parameters[group], name, p.Type, p.Required, properties[p.Properties]}
+
+			if err := tx.insert(&row); err != nil {
+				return written, err
+			}
+			written.parameters = append(written.parameters, row)
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(d.Endpoints)) {
+		e := d.Endpoints[path]
+		row := _endpoint{ids[path], path, parameters[e.UriParams], 0, e.Table, 0}
+
+		if len(e.Methods) != 0 {
+			id, err := tx.allocate("method")
+			if err != nil {
+				return written, err
+			}
+			row.Methods = id
+		}
+
+		for _, verb := range slices.Sorted(maps.Keys(e.Methods)) {
+			m := e.Methods[verb]
+			method := _method{row.Methods, verb, parameters[m.Query], parameters[m.Headers], parameters[m.Body], 0}
+
+			if len(m.Examples) != 0 {
+				id, err := tx.allocate("example")
+				if err != nil {
+					return written, err
+				}
+				method.Examples = id
+			}
+
+			for _, name := range slices.Sorted(maps.Keys(m.Examples)) {
+				example := m.Examples[name]
+				headers, _ := json.Marshal(example.Headers)
+				ex := _example{method.Examples, name, example.Status, string(headers), example.Body}
+
+				if err := tx.insert(&ex); err != nil {
+					return written, err
+				}
+				written.examples = append(written.examples, ex)
+			}
+
+			if err := tx.insert(&method); err != nil {
+				return written, err
+			}
+			written.methods = append(written.methods, method)
+		}
+
+		if u := e.Upstream; u != nil {
+			inject, _ := json.Marshal(u.Inject)
+			upstream := _upstream{0, u.Target, u.Timeout, strings.Join(u.Passthrough, ","), string(inject)}
+
+			if err := tx.insert(&upstream); err != nil {
+				return written, err
+			}
+			row.Upstream = upstream.Id
+			written.upstreams = append(written.upstreams, upstream)
+		}
+
+		if err := tx.insert(&row); err != nil {
+			return written, err
+		}
+		written.endpoints = append(written.endpoints, row)
+	}
+
+	return written, nil
+}
+
+// merge adds the rows written for a document to the registry
+func (registry _registry) merge(written rows) {
+	for _, p := range written.properties {
+		if registry.properties[p.Id] == nil {
+			registry.properties[p.Id] = make(map[string]string)
+		}
+		registry.properties[p.Id][p.Name] = p.Value
+	}
+
+	for _, p := range written.parameters {
+		if registry.parameters[p.Id] == nil {
+			registry.parameters[p.Id] = make(map[string]_parameter)
+		}
+		registry.parameters[p.Id][p.Name] = p
+	}
+
+	for _, e := range written.examples {
+		if registry.examples[e.Id] == nil {
+			registry.examples[e.Id] = make(map[string]_example)
+		}
+		registry.examples[e.Id][e.Name] = e
+	}
+
+	for _, m := range written.methods {
+		if registry.methods[m.Id] == nil {
+			registry.methods[m.Id] = make(map[string]_method)
+		}
+		registry.methods[m.Id][m.Name] = m
+	}
+
+	for _, u := range written.upstreams {
+		registry.upstreams[u.Id] = u
+	}
+
+	for _, e := range written.endpoints {
+		registry.endpoints[e.Id] = e
+	}
+}
+
+// snapshot describes the registry as a document, naming every group after
+// the first entry found to reference it
+func (registry _registry) snapshot() document {
+	var d = document{
+		Endpoints:  make(map[string]documentEndpoint),
+		Parameters: make(map[string]map[string]documentParameter),
+		Properties: make(map[string]map[string]string),
+	}
+
+	var parameters = make(map[int]string)
+	var queue []int
+	var name = func(group int, as string) string {
+		if _, ok := registry.parameters[group]; !ok {
+			return ""
+		}
+		if _, ok := parameters[group]; !ok {
+			parameters[group] = as
+			queue = append(queue, group)
+		}
+		return parameters[group]
+	}
+
+	var endpoints = slices.Collect(maps.Values(registry.endpoints))
+	slices.SortFunc(endpoints, func(a, b _endpoint) int { return strings.Compare(a.Path, b.Path) })
+
+	for _, e := range endpoints {
+		var endpoint = documentEndpoint{
+			UriParams: name(e.UriParams, e.Path+" uri"),
+			Table:     e.Table,
+			Methods:   make(map[string]documentMethod),
+		}
+
+		if u, ok := registry.upstreams[e.Upstream]; ok {
+			var passthrough []string
+			if u.Passthrough != "" {
+				passthrough = strings.Split(u.Passthrough, ",")
+			}
+			var inject map[string]string
+			json.Unmarshal([]byte(u.Inject), &inject)
+			endpoint.Upstream = &documentUpstream{u.Target, u.Timeout, passthrough, inject}
+		}
+
+		methods := registry.methods[e.Methods]
+		for _, verb := range slices.Sorted(maps.Keys(methods)) {
+			m := methods[verb]
+			method := documentMethod{
+				Query:   name(m.Query, verb+" "+e.Path+" query"),
+				Headers: name(m.Headers, verb+" "+e.Path+" headers"),
+				Body:    name(m.Body, verb+" "+e.Path+" body"),
+			}
+
+			for example, ex := range registry.examples[m.Examples] {
+				if method.Examples == nil {
+					method.Examples = make(map[string]documentExample)
+				}
+				var headers map[string]string
+				json.Unmarshal([]byte(ex.Headers), &headers)
+				method.Examples[example] = documentExample{ex.Status, headers, ex.Body}
+			}
+
+			endpoint.Methods[verb] = method
+		}
+
+		d.Endpoints[e.Path] = endpoint
+	}
+
+	var properties = make(map[int]string)
+	var drain = func() {
+		for len(queue) != 0 {
+			group := queue[0]
+			queue = queue[1:]
+
+			params := make(map[string]documentParameter)
+			for _, param := range slices.Sorted(maps.Keys(registry.parameters[group])) {
+				p := registry.parameters[group][param]
+				if _, ok := registry.properties[p.Properties]; ok {
+					if _, ok := properties[p.Properties]; !ok {
+						properties[p.Properties] = parameters[group] + " " + param
+					}
+				}
+				params[param] = documentParameter{p.Type, p.Required, properties[p.Properties]}
+
+				schema, _ := strconv.Atoi(registry.properties[p.Properties]["schema"])
+				name(schema, parameters[group]+" "+param)
+			}
+			d.Parameters[parameters[group]] = params
+		}
+	}
+
+	drain()
+
+	// groups left unreferenced keep their id as the only name they have
+	for _, group := range slices.Sorted(maps.Keys(registry.parameters)) {
+		name(group, "parameters "+strconv.Itoa(group))
+		drain()
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(registry.properties)) {
+		if _, ok := properties[group]; !ok {
+			properties[group] = "properties " + strconv.Itoa(group)
+		}
+
+		props := maps.Clone(registry.properties[group])
+		if schema, ok := props["schema"]; ok {
+			id, _ := strconv.Atoi(schema)
+			props["schema"] = parameters[id]
+		}
+		d.Properties[properties[group]] = props
+	}
+
+	return d
+}
@@ -0,0 +1,273 @@
+package system
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"text/template"
+
+	"Factory/api"
+	"Factory/internal/system/rest"
+	"Factory/internal/util"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// mocks prepares the examples of a group for rest.Mock,
+// leaving out those whose body template does not parse
+func (registry _registry) mocks(group int) map[string]rest.Example {
+	var examples = make(map[string]rest.Example)
+
+	for name, e := range registry.examples[group] {
+		body, err := template.New(name).Parse(e.Body)
+		if err != nil {
+			logrus.WithField("example", name).Error(err)
+			continue
+		}
+
+		var headers map[string]string
+		json.Unmarshal([]byte(e.Headers), &headers)
+
+		examples[name] = rest.Example{
+			Status:  e.Status,
+			Headers: headers,
+			Body:    body,
+		}
+	}
+
+	return examples
+}
+
+// discard removes the example groups of deleted methods
+// within the transaction and from the registry alike
+func (registry _registry) discard(tx writer, groups ...int) error {
+	for _, group := range groups {
+		if group == 0 {
+			continue
+		}
+
+		if err := tx.remove("example", group, ""); err != nil {
+			return err
+		}
+		delete(registry.examples, group)
+	}
+
+	return nil
+}
+
+func checkExample(w http.ResponseWriter, r *http.Request, e _example) bool {
+	if _, err := template.New(e.Name).Parse(e.Body); err != nil {
+		message := "body of example %s is not a valid template: %s"
+		message = util.Message(message, e.Name, err.Error())
+		api.RequestErrorHandler(w, r, message)
+		return false
+	}
+
+	if e.Status != 0 && (e.Status < 100 || e.Status > 599) {
+		message := "status (%v) of example %s must be a valid HTTP status code"
+		message = util.Message(message, e.Status, e.Name)
+		api.RequestErrorHandler(w, r, message)
+		return false
+	}
+
+	return true
+}
+
+func GetSystemExamples(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := registry.methodFrom(w, r, e)
+	if !ok {
+		return
+	}
+
+	var display = make(map[string]api.GetSystemExample)
+	for name, example := range registry.examples[m.Examples] {
+		var headers map[string]string
+		json.Unmarshal([]byte(example.Headers), &headers)
+
+		display[name] = api.GetSystemExample{
+			Status:  example.Status,
+			Headers: headers,
+			Body:    example.Body,
+		}
+	}
+
+	json.NewEncoder(w).Encode(display)
+}
+
+func PostSystemExample(w http.ResponseWriter, r *http.Request) {
+	var request api.PostSystemExampleRequest
+	json.NewDecoder(r.Body).Decode(&request)
+
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := registry.methodFrom(w, r, e)
+	if !ok {
+		return
+	}
+
+	name := chi.URLParam(r, "example")
+	if _, ok = registry.examples[m.Examples][name]; ok {
+		message := "example %s is already registered for %s %s"
+		message = util.Message(message, name, m.Name, e.Path)
+		api.ConflictErrorHandler(w, r, message)
+		return
+	}
+
+	headers, _ := json.Marshal(request.Headers)
+	example := _example{
+		Id:      m.Examples,
+		Name:    name,
+		Status:  request.Status,
+		Headers: string(headers),
+		Body:    request.Body,
+	}
+
+	if !checkExample(w, r, example) {
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		if err := tx.insert(&example); err != nil || m.Examples != 0 {
+			return err
+		}
+
+		mocked := m
+		mocked.Examples = example.Id
+		return tx.update(&mocked)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	if m.Examples == 0 {
+		m.Examples = example.Id
+		registry.methods[m.Id][m.Name] = m
+		registry.examples[m.Examples] = make(map[string]_example)
+	}
+
+	registry.examples[m.Examples][name] = example
+	publish(registry)
+
+	message := "Successfully registered example %s for %s %s"
+	message = util.Message(message, name, m.Name, e.Path)
+	api.SuccessfulSystemPost(w, r, message)
+}
+
+func (registry _registry) exampleFrom(w http.ResponseWriter, r *http.Request) (_endpoint, _method, _example, bool) {
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return e, _method{}, _example{}, false
+	}
+
+	m, ok := registry.methodFrom(w, r, e)
+	if !ok {
+		return e, m, _example{}, false
+	}
+
+	name := chi.URLParam(r, "example")
+	example, ok := registry.examples[m.Examples][name]
+	if !ok {
+		message := "no example named %s for %s %s"
+		message = util.Message(message, name, m.Name, e.Path)
+		api.NotFoundErrorHandler(w, r, message)
+	}
+
+	return e, m, example, ok
+}
+
+func PutSystemExample(w http.ResponseWriter, r *http.Request) {
+	var request api.PutSystemExampleRequest
+	json.NewDecoder(r.Body).Decode(&request)
+
+	registry, done := edit()
+	defer done()
+
+	e, m, example, ok := registry.exampleFrom(w, r)
+	if !ok {
+		return
+	}
+
+	headers, _ := json.Marshal(request.Headers)
+	example.Status = request.Status
+	example.Headers = string(headers)
+	example.Body = request.Body
+
+	if !checkExample(w, r, example) {
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.update(&example)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry.examples[example.Id][example.Name] = example
+	publish(registry)
+
+	message := "Successfully replaced example %s for %s %s"
+	message = util.Message(message, example.Name, m.Name, e.Path)
+	api.SuccessfulSystemPut(w, r, message)
+}
+
+func DeleteSystemExample(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	e, m, example, ok := registry.exampleFrom(w, r)
+	if !ok {
+		return
+	}
+
+	examples := maps.Clone(registry.examples[example.Id])
+	delete(examples, example.Name)
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		if err := tx.remove("example", example.Id, example.Name); err != nil {
+			return err
+		}
+
+		// the method stops being mocked along with its last example
+		if len(examples) != 0 {
+			return nil
+		}
+
+		unmocked := m
+		unmocked.Examples = 0
+		return tx.update(&unmocked)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	if len(examples) == 0 {
+		m.Examples = 0
+		registry.methods[m.Id][m.Name] = m
+		delete(registry.examples, example.Id)
+	} else {
+		registry.examples[example.Id] = examples
+	}
+	publish(registry)
+
+	message := "Successfully removed example %s from %s %s"
+	message = util.Message(message, example.Name, m.Name, e.Path)
+	api.SuccessfulSystemDelete(w, r, message)
+}
@@ -0,0 +1,224 @@
+package system
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+)
+
+// policies lists how an import may treat endpoints that are already registered
+var policies = []string{"skip", "overwrite", "fail"}
+
+// prune leaves out the groups no remaining endpoint uses, unless they were
+// standalone in the original document and are not yet present in the catalog
+func (d document) prune(existing document, parameters, properties map[string]string) document {
+	var have = make(map[string]bool)
+
+	haveParameters, haveProperties := existing.standalone()
+	for _, shape := range haveParameters {
+		have["parameter "+shape] = true
+	}
+	for _, shape := range haveProperties {
+		have["property "+shape] = true
+	}
+
+	var reached = d.reachable()
+	for name := range d.Parameters {
+		shape, standalone := parameters[name]
+		if !reached[name] && (!standalone || have["parameter "+shape]) {
+			delete(d.Parameters, name)
+		}
+	}
+
+	var used = make(map[string]bool)
+	for _, params := range d.Parameters {
+		for _, p := range params {
+			used[p.Properties] = true
+		}
+	}
+	for name := range d.Properties {
+		shape, standalone := properties[name]
+		if !used[name] && (!standalone || have["property "+shape]) {
+			delete(d.Properties, name)
+		}
+	}
+
+	return d
+}
+
+func GetSystemExport(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
+
+	if r.URL.Query().Get("format") != "yaml" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(registry.snapshot())
+		return
+	}
+
+	document, err := util.YAML(registry.snapshot())
+	if err != nil {
+		util.GetLogger(r).Error(err)
+		api.InternalErrorHandler(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(document)
+}
+
+func PostSystemImport(w http.ResponseWriter, r *http.Request) {
+	var imported document
+
+	var policy = r.URL.Query().Get("conflict")
+	if policy == "" {
+		policy = "fail"
+	}
+	if !slices.Contains(policies, policy) {
+		message := "conflict policy (%s) must be one of %s"
+		message = util.Message(message, policy, strings.Join(policies, ","))
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	if !parseRequest(w, r, "snapshot", &imported) {
+		return
+	}
+
+	if problems := imported.check(r.Context()); len(problems) != 0 {
+		message := "snapshot is not valid: %s"
+		message = util.Message(message, strings.Join(problems, ", "))
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	registry, done := edit()
+	defer done()
+
+	var conflicts []_endpoint
+	for _, e := range registry.endpoints {
+		if _, ok := imported.Endpoints[e.Path]; ok {
+			conflicts = append(conflicts, e)
+		}
+	}
+	slices.SortFunc(conflicts, func(a, b _endpoint) int { return strings.Compare(a.Path, b.Path) })
+
+	var report = api.PostSystemImportReport{Policy: policy}
+	var ids = make(map[string]int)
+
+	if len(conflicts) != 0 && policy == "fail" {
+		var paths []string
+		for _, e := range conflicts {
+			paths = append(paths, e.Path)
+		}
+
+		message := "%s already registered"
+		message = util.Message(message, strings.Join(paths, ", "))
+		api.ConflictErrorHandler(w, r, message)
+		return
+	}
+
+	parameters, properties := imported.standalone()
+	for _, e := range conflicts {
+		if policy == "skip" {
+			report.Skipped = append(report.Skipped, e.Path)
+			delete(imported.Endpoints, e.Path)
+		} else {
+			report.Overwritten = append(report.Overwritten, e.Path)
+			ids[e.Path] = e.Id
+		}
+	}
+
+	// the paths imported are routed alongside those left in place
+	var paths = slices.Collect(maps.Keys(imported.Endpoints))
+	for _, e := range registry.endpoints {
+		if _, replaced := ids[e.Path]; !replaced {
+			paths = append(paths, e.Path)
+		}
+	}
+	if err := checkRoutes(paths...); err != nil {
+		message := "snapshot conflicts with the registered endpoints: %s"
+		message = util.Message(message, err.Error())
+		api.ConflictErrorHandler(w, r, message)
+		return
+	}
+
+	imported = imported.prune(registry.snapshot(), parameters, properties)
+	report.Created = imported.summary()
+
+	// overwritten endpoints give up their methods, examples and upstream,
+	// method groups shared with other endpoints being left in place
+	var groups, examples, methods []int
+	for _, e := range conflicts {
+		if policy != "overwrite" {
+			break
+		}
+
+		shared := false
+		for _, other := range registry.endpoints {
+			_, replaced := ids[other.Path]
+			shared = shared || (other.Methods == e.Methods && !replaced)
+		}
+
+		groups = append(groups, e.UriParams)
+		if e.Methods != 0 && !shared {
+			methods = append(methods, e.Methods)
+			for _, m := range registry.methods[e.Methods] {
+				groups = append(groups, m.Query, m.Headers, m.Body)
+				examples = append(examples, m.Examples)
+			}
+		}
+	}
+
+	var written rows
+	err := store.transaction(r.Context(), func(tx writer) (err error) {
+		for _, path := range report.Overwritten {
+			e := registry.endpoints[ids[path]]
+			if err = tx.remove("endpoint", e.Id, ""); err != nil {
+				return err
+			}
+			if err = tx.remove("upstream", e.Upstream, ""); err != nil {
+				return err
+			}
+		}
+
+		for _, group := range methods {
+			if err = tx.remove("method", group, ""); err != nil {
+				return err
+			}
+		}
+		if err = registry.discard(tx, examples...); err != nil {
+			return err
+		}
+
+		written, err = install(tx, imported, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			e := registry.endpoints[id]
+			delete(registry.endpoints, e.Id)
+			delete(registry.upstreams, e.Upstream)
+		}
+		for _, group := range methods {
+			delete(registry.methods, group)
+		}
+		registry.merge(written)
+
+		return registry.release(tx, groups...)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+	publish(registry)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
@@ -24,12 +24,13 @@ func isId(value string) (int, error) {
 }
 
 func GetSystemEndpoints(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
 	var base = r.URL.Query().Get("basePath")
 	var endpoints = make(map[string]int)
 
 	for i, e := range registry.endpoints {
-		if strings.HasPrefix(e.path, base) {
-			endpoints[e.path] = i
+		if strings.HasPrefix(e.Path, base) {
+			endpoints[e.Path] = i
 		}
 	}
 
@@ -37,6 +38,7 @@ func GetSystemEndpoints(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetSystemEndpointById(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
 	var endpoint = chi.URLParam(r, "endpoint")
 
 	id, err := isId(endpoint)
@@ -54,24 +56,28 @@ func GetSystemEndpointById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var methods = make(JObject)
-	if ms, ok := registry.methods[route.methods]; ok {
+	if ms, ok := registry.methods[route.Methods]; ok {
 		for verb, m := range ms {
 			methods[verb] = api.GetSystemEndpointsMethod{
-				Query:   m.query,
-				Headers: m.headers,
+				Query:   m.Query,
+				Headers: m.Headers,
+				Body:    m.Body,
 			}
 		}
 	}
 
 	json.NewEncoder(w).Encode(api.GetSystemEndpoints{
-		Path:       route.path,
-		UriParams:  route.uriParams,
-		Methods:    route.methods,
+		Path:       route.Path,
+		UriParams:  route.UriParams,
+		Methods:    route.Methods,
+		Table:      route.Table,
+		Upstream:   route.Upstream,
 		Configured: methods,
 	})
 }
 
 func GetSystemMethod(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
 	var endpoint = chi.URLParam(r, "endpoint")
 	var verb = chi.URLParam(r, "method")
 
@@ -89,10 +95,10 @@ func GetSystemMethod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	methods, ok := registry.methods[route.methods]
+	methods, ok := registry.methods[route.Methods]
 	if !ok {
 		message := "no methods defined for %s"
-		message = util.Message(message, route.path)
+		message = util.Message(message, route.Path)
 		api.NotFoundErrorHandler(w, r, message)
 		return
 	}
@@ -101,21 +107,24 @@ func GetSystemMethod(w http.ResponseWriter, r *http.Request) {
 	method, ok := methods[verb]
 	if !ok {
 		message := "not defined for %s"
-		message = util.Message(message, route.path)
+		message = util.Message(message, route.Path)
 		api.NotFoundErrorHandler(w, r, message)
 		return
 	}
 
 	json.NewEncoder(w).Encode(api.GetSystemMethod{
-		Id:      method.id,
-		Method:  method.name,
-		Uri:     route.uriParams,
-		Query:   method.query,
-		Headers: method.headers,
+		Id:       method.Id,
+		Method:   method.Name,
+		Uri:      route.UriParams,
+		Query:    method.Query,
+		Headers:  method.Headers,
+		Body:     method.Body,
+		Examples: method.Examples,
 	})
 }
 
 func GetSystemParameters(w http.ResponseWriter, _ *http.Request) {
+	var registry = current()
 	var display = make(map[int]map[string]string)
 
 	for id, params := range registry.parameters {
@@ -123,7 +132,7 @@ func GetSystemParameters(w http.ResponseWriter, _ *http.Request) {
 			display[id] = make(map[string]string)
 		}
 		for name, p := range params {
-			display[id][name] = p.typ
+			display[id][name] = p.Type
 		}
 	}
 
@@ -131,6 +140,8 @@ func GetSystemParameters(w http.ResponseWriter, _ *http.Request) {
 }
 
 func GetSystemParameterById(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
+
 	parameter := chi.URLParam(r, "parameter")
 
 	id, err := isId(parameter)
@@ -150,9 +161,9 @@ func GetSystemParameterById(w http.ResponseWriter, r *http.Request) {
 	var display = make(map[string]any)
 	for name, p := range params {
 		display[name] = api.GetSystemParametersById{
-			Type:       p.typ,
-			Required:   p.required,
-			Properties: registry.properties[p.properties],
+			Type:       p.Type,
+			Required:   p.Required,
+			Properties: registry.properties[p.Properties],
 		}
 	}
 
@@ -160,10 +171,14 @@ func GetSystemParameterById(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetSystemProperties(w http.ResponseWriter, _ *http.Request) {
+	var registry = current()
+
 	json.NewEncoder(w).Encode(registry.properties)
 }
 
 func GetSystemPropertyById(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
+
 	property := chi.URLParam(r, "property")
 
 	id, err := isId(property)
@@ -181,14 +196,30 @@ func GetSystemPropertyById(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func GetSystemMigrations(w http.ResponseWriter, r *http.Request) {
+	if util.Database.Conn == nil {
+		message := "migrations require the postgres storage"
+		api.NotImplementedErrorHandler(w, r, message)
+		return
+	}
+
+	status, err := util.Database.MigrationStatus()
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
 func PostSystemEndpoint(w http.ResponseWriter, r *http.Request) {
-	var db = util.Database
 	var endpoint string
-
 	json.NewDecoder(r.Body).Decode(&endpoint)
 
-	endpoint = strings.Trim(endpoint, " /")
-	endpoint = "/" + endpoint
+	registry, done := edit()
+	defer done()
+
+	endpoint = normalize(endpoint)
 	if endpoint == "/" {
 		message := "endpoint path must be provided"
 		api.RequestErrorHandler(w, r, message)
@@ -196,40 +227,52 @@ func PostSystemEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, e := range registry.endpoints {
-		if e.path == endpoint {
+		if e.Path == endpoint {
 			message := "%s is already registered"
-			message = util.Message(message, e.path)
-			api.RequestErrorHandler(w, r, message)
+			message = util.Message(message, e.Path)
+			api.ConflictErrorHandler(w, r, message)
 			return
 		}
 	}
 
-	var id int
-	sql := `INSERT INTO endpoint(path, "uriParams", methods)
-			VALUES ($1, 0, 0) RETURNING id`
-	_ = db.QueryRow(&id, sql, endpoint)
+	if !registry.routable(w, r, endpoint, 0) {
+		return
+	}
 
-	registry.endpoints[id] = _endpoint{
-		id,
-		endpoint,
-		0,
-		0,
+	var e = _endpoint{Path: endpoint}
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.insert(&e)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
 	}
 
+	registry.endpoints[e.Id] = e
+	publish(registry)
+
 	message := "Successfully registered endpoint %s"
 	message = util.Message(message, endpoint)
 	api.SuccessfulSystemPost(w, r, message)
 }
 
 func PostSystemMethod(w http.ResponseWriter, r *http.Request) {
-	var db = util.Database
-
 	var method api.PostSystemMethodRequest
 	json.NewDecoder(r.Body).Decode(&method)
 
+	registry, done := edit()
+	defer done()
+
 	method.Name = chi.URLParam(r, "method")
 	method.Name = strings.ToUpper(method.Name)
 
+	if _, ok := handlers[method.Name]; !ok {
+		message := "%s is not a supported method"
+		message = util.Message(message, method.Name)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
 	endpoint := chi.URLParam(r, "endpoint")
 	id, err := strconv.Atoi(endpoint)
 	if err != nil {
@@ -247,35 +290,436 @@ func PostSystemMethod(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok = registry.methods[e.methods][method.Name]; ok {
+	if _, ok = registry.methods[e.Methods][method.Name]; ok {
 		message := "%s is already registered for %s"
-		message = util.Message(message, method.Name, e.path)
-		api.RequestErrorHandler(w, r, message)
+		message = util.Message(message, method.Name, e.Path)
+		api.ConflictErrorHandler(w, r, message)
 		return
 	}
 
-	if e.methods == 0 {
-		sql := `INSERT INTO method (id, name, headers, query) VALUES (DEFAULT, $1, $2, $3) RETURNING id`
-		_ = db.QueryRow(&e.methods, sql, method.Name, method.Headers, method.Query)
-
-		sql = `UPDATE endpoint SET methods = $1 WHERE id = $2`
-		_ = db.QueryRow(nil, sql, e.methods, e.id)
+	if !registry.parametersExist(w, r, method.Query, method.Headers, method.Body) {
+		return
+	}
 
-		registry.endpoints[id] = e
-		registry.methods[e.methods] = make(map[string]_method)
-	} else {
-		sql := `INSERT INTO method (id, name, headers, query) VALUES ($1, $2, $3, $4)`
-		_ = db.QueryRow(nil, sql, e.methods, method.Name, method.Headers, method.Query)
+	var m = _method{
+		Id:      e.Methods,
+		Name:    method.Name,
+		Query:   method.Query,
+		Headers: method.Headers,
+		Body:    method.Body,
 	}
+	err = store.transaction(r.Context(), func(tx writer) error {
+		if err := tx.insert(&m); err != nil || e.Methods != 0 {
+			return err
+		}
 
-	registry.methods[e.methods][method.Name] = _method{
-		id:      e.methods,
-		name:    method.Name,
-		query:   method.Query,
-		headers: method.Headers,
+		e.Methods = m.Id
+		return tx.update(&e)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
 	}
 
+	registry.endpoints[id] = e
+	registry.add(m)
+	publish(registry)
+
 	message := "Successfully registered method %s for %s"
-	message = util.Message(message, method.Name, e.path)
+	message = util.Message(message, method.Name, e.Path)
 	api.SuccessfulSystemPost(w, r, message)
 }
+
+// routable checks that path can be routed on its own and alongside
+// the paths of the endpoints registered besides the one of id
+func (registry _registry) routable(w http.ResponseWriter, r *http.Request, path string, id int) bool {
+	if err := checkRoutes(path); err != nil {
+		message := "endpoint path %s is not valid: %s"
+		message = util.Message(message, path, err.Error())
+		api.RequestErrorHandler(w, r, message)
+		return false
+	}
+
+	if err := checkRoutes(append(registry.paths(id), path)...); err != nil {
+		message := "endpoint path %s conflicts with a registered endpoint: %s"
+		message = util.Message(message, path, err.Error())
+		api.ConflictErrorHandler(w, r, message)
+		return false
+	}
+
+	return true
+}
+
+func normalize(path string) string {
+	path = strings.Trim(path, " /")
+	return "/" + path
+}
+
+func (registry _registry) endpointFrom(w http.ResponseWriter, r *http.Request) (_endpoint, bool) {
+	var endpoint = chi.URLParam(r, "endpoint")
+
+	id, err := isId(endpoint)
+	if err != nil {
+		api.RequestErrorHandler(w, r, err.Error())
+		return _endpoint{}, false
+	}
+
+	e, ok := registry.endpoints[id]
+	if !ok {
+		message := "no endpoint found with id %s"
+		message = util.Message(message, endpoint)
+		api.NotFoundErrorHandler(w, r, message)
+	}
+
+	return e, ok
+}
+
+func (registry _registry) methodFrom(w http.ResponseWriter, r *http.Request, e _endpoint) (_method, bool) {
+	var verb = chi.URLParam(r, "method")
+	verb = strings.ToUpper(verb)
+
+	m, ok := registry.methods[e.Methods][verb]
+	if !ok {
+		message := "%s is not defined for %s"
+		message = util.Message(message, verb, e.Path)
+		api.NotFoundErrorHandler(w, r, message)
+	}
+
+	return m, ok
+}
+
+// saveEndpoint replaces the stored definition of an existing endpoint after checking
+// the path is unique and the method groups, parameter groups and table exist
+func (registry _registry) saveEndpoint(w http.ResponseWriter, r *http.Request, e _endpoint) bool {
+	if e.Path == "/" {
+		message := "endpoint path must be provided"
+		api.RequestErrorHandler(w, r, message)
+		return false
+	}
+
+	for _, other := range registry.endpoints {
+		if other.Path == e.Path && other.Id != e.Id {
+			message := "%s is already registered"
+			message = util.Message(message, e.Path)
+			api.ConflictErrorHandler(w, r, message)
+			return false
+		}
+	}
+
+	if !registry.routable(w, r, e.Path, e.Id) {
+		return false
+	}
+
+	if !registry.parametersExist(w, r, e.UriParams) {
+		return false
+	}
+
+	if _, ok := registry.methods[e.Methods]; !ok && e.Methods != 0 {
+		message := "method group %v does not exist"
+		message = util.Message(message, e.Methods)
+		api.NotFoundErrorHandler(w, r, message)
+		return false
+	}
+
+	if e.Table != "" {
+		if bindable, err := store.bindable(r.Context(), e.Table); err != nil || !bindable {
+			message := "table %s does not exist or may not be bound"
+			message = util.Message(message, e.Table)
+			api.NotFoundErrorHandler(w, r, message)
+			return false
+		}
+	}
+
+	// the groups the endpoint lets go of are left in place,
+	// being managed through the admin API of their own
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.update(&e)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return false
+	}
+
+	registry.endpoints[e.Id] = e
+	publish(registry)
+	return true
+}
+
+// dropMethods removes a method group no endpoint is attached to anymore,
+// along with its examples and the parameter groups only it referenced
+func (registry _registry) dropMethods(tx writer, group int) error {
+	if _, ok := registry.methods[group]; !ok {
+		return nil
+	}
+	for _, e := range registry.endpoints {
+		if e.Methods == group {
+			return nil
+		}
+	}
+
+	var groups, examples []int
+	for _, m := range registry.methods[group] {
+		groups = append(groups, m.Query, m.Headers, m.Body)
+		examples = append(examples, m.Examples)
+	}
+
+	if err := tx.remove("method", group, ""); err != nil {
+		return err
+	}
+	delete(registry.methods, group)
+
+	if err := registry.discard(tx, examples...); err != nil {
+		return err
+	}
+	return registry.release(tx, groups...)
+}
+
+// release removes, within the transaction of the change that let go of them,
+// the parameter groups no longer referenced by any endpoint or method in the
+// registry, which must therefore already reflect that change
+func (registry _registry) release(tx writer, groups ...int) error {
+	for _, group := range groups {
+		if _, ok := registry.parameters[group]; !ok || registry.parametersReferenced(group) {
+			continue
+		}
+
+		if err := tx.remove("parameter", group, ""); err != nil {
+			return err
+		}
+		delete(registry.parameters, group)
+	}
+
+	return nil
+}
+
+func PutSystemEndpoint(w http.ResponseWriter, r *http.Request) {
+	var endpoint api.PutSystemEndpointRequest
+	json.NewDecoder(r.Body).Decode(&endpoint)
+
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	previous := e.Path
+	e.Path = normalize(endpoint.Path)
+	e.Methods = endpoint.Methods
+	e.UriParams = endpoint.UriParams
+	e.Table = endpoint.Table
+
+	if registry.saveEndpoint(w, r, e) {
+		message := "Successfully replaced endpoint %s with %s"
+		message = util.Message(message, previous, e.Path)
+		api.SuccessfulSystemPut(w, r, message)
+	}
+}
+
+func PatchSystemEndpoint(w http.ResponseWriter, r *http.Request) {
+	var endpoint api.PatchSystemEndpointRequest
+	json.NewDecoder(r.Body).Decode(&endpoint)
+
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	if endpoint.Path != nil {
+		e.Path = normalize(*endpoint.Path)
+	}
+	if endpoint.Methods != nil {
+		e.Methods = *endpoint.Methods
+	}
+	if endpoint.UriParams != nil {
+		e.UriParams = *endpoint.UriParams
+	}
+	if endpoint.Table != nil {
+		e.Table = *endpoint.Table
+	}
+
+	if registry.saveEndpoint(w, r, e) {
+		message := "Successfully updated endpoint %s"
+		message = util.Message(message, e.Path)
+		api.SuccessfulSystemPatch(w, r, message)
+	}
+}
+
+func DeleteSystemEndpoint(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	// the registry is a private copy until published, so it
+	// follows the transaction for release to see the outcome
+	err := store.transaction(r.Context(), func(tx writer) error {
+		if err := tx.remove("endpoint", e.Id, ""); err != nil {
+			return err
+		}
+		delete(registry.endpoints, e.Id)
+
+		if e.Upstream != 0 {
+			if err := tx.remove("upstream", e.Upstream, ""); err != nil {
+				return err
+			}
+			delete(registry.upstreams, e.Upstream)
+		}
+
+		// the method group goes unless other endpoints are attached to it
+		if err := registry.dropMethods(tx, e.Methods); err != nil {
+			return err
+		}
+		return registry.release(tx, e.UriParams)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+	publish(registry)
+
+	message := "Successfully removed endpoint %s"
+	message = util.Message(message, e.Path)
+	api.SuccessfulSystemDelete(w, r, message)
+}
+
+// saveMethod replaces the parameter groups and body schema of an existing method
+func (registry _registry) saveMethod(w http.ResponseWriter, r *http.Request, m _method) bool {
+	if !registry.parametersExist(w, r, m.Query, m.Headers, m.Body) {
+		return false
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.update(&m)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return false
+	}
+
+	registry.methods[m.Id][m.Name] = m
+	publish(registry)
+	return true
+}
+
+func PutSystemMethod(w http.ResponseWriter, r *http.Request) {
+	var method api.PutSystemMethodRequest
+	json.NewDecoder(r.Body).Decode(&method)
+
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := registry.methodFrom(w, r, e)
+	if !ok {
+		return
+	}
+
+	m.Query = method.Query
+	m.Headers = method.Headers
+	m.Body = method.Body
+
+	if registry.saveMethod(w, r, m) {
+		message := "Successfully replaced method %s for %s"
+		message = util.Message(message, m.Name, e.Path)
+		api.SuccessfulSystemPut(w, r, message)
+	}
+}
+
+func PatchSystemMethod(w http.ResponseWriter, r *http.Request) {
+	var method api.PatchSystemMethodRequest
+	json.NewDecoder(r.Body).Decode(&method)
+
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := registry.methodFrom(w, r, e)
+	if !ok {
+		return
+	}
+
+	if method.Query != nil {
+		m.Query = *method.Query
+	}
+	if method.Headers != nil {
+		m.Headers = *method.Headers
+	}
+	if method.Body != nil {
+		m.Body = *method.Body
+	}
+
+	if registry.saveMethod(w, r, m) {
+		message := "Successfully updated method %s for %s"
+		message = util.Message(message, m.Name, e.Path)
+		api.SuccessfulSystemPatch(w, r, message)
+	}
+}
+
+func DeleteSystemMethod(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := registry.methodFrom(w, r, e)
+	if !ok {
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		if err := tx.remove("method", m.Id, m.Name); err != nil {
+			return err
+		}
+		delete(registry.methods[m.Id], m.Name)
+
+		// without methods left the group no longer exists, so
+		// the endpoints attached to it are left without methods
+		if len(registry.methods[m.Id]) == 0 {
+			delete(registry.methods, m.Id)
+			for _, other := range registry.endpoints {
+				if other.Methods != m.Id {
+					continue
+				}
+
+				other.Methods = 0
+				if err := tx.update(&other); err != nil {
+					return err
+				}
+				registry.endpoints[other.Id] = other
+			}
+		}
+
+		if err := registry.discard(tx, m.Examples); err != nil {
+			return err
+		}
+		return registry.release(tx, m.Query, m.Headers, m.Body)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+	publish(registry)
+
+	message := "Successfully removed method %s from %s"
+	message = util.Message(message, m.Name, e.Path)
+	api.SuccessfulSystemDelete(w, r, message)
+}
@@ -0,0 +1,320 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Factory/api"
+	"Factory/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+// serve starts a catalog kept in memory, as main does when STORAGE is memory
+func serve(t *testing.T) http.Handler {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Use(middleware.Correlation)
+	Initialize(r, "memory", "")
+	return r
+}
+
+// call sends body encoded as JSON, or as is when it is a string of a document,
+// failing the test unless the response has the expected status
+func call(t *testing.T, h http.Handler, method, path string, body any, status int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var data []byte
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		data = body
+	default:
+		data, _ = json.Marshal(body)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(data)))
+	if w.Code != status {
+		t.Fatalf("%s %s: expected %v, got %v: %s", method, path, status, w.Code, w.Body.String())
+	}
+	return w
+}
+
+// endpoint returns the definition of an endpoint as the admin API describes it
+func endpoint(t *testing.T, h http.Handler, id string) api.GetSystemEndpoints {
+	t.Helper()
+
+	var e api.GetSystemEndpoints
+	json.NewDecoder(call(t, h, "GET", "/system/endpoints/"+id, nil, 200).Body).Decode(&e)
+	return e
+}
+
+// mocked registers an endpoint answering GET with a mocked example
+func mocked(t *testing.T, h http.Handler, path string, method api.PostSystemMethodRequest) {
+	t.Helper()
+
+	call(t, h, "POST", "/system/endpoints", path, 200)
+	call(t, h, "POST", "/system/endpoints/1/GET", method, 200)
+	call(t, h, "POST", "/system/endpoints/1/GET/examples/default", api.PostSystemExampleRequest{Body: "mocked"}, 200)
+}
+
+func TestRegisterEndpoint(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/endpoints", "users", 200)
+	call(t, h, "POST", "/system/endpoints", "/users/", 409)
+	call(t, h, "POST", "/system/endpoints", "", 400)
+
+	call(t, h, "POST", "/system/endpoints/1/get", api.PostSystemMethodRequest{}, 200)
+	call(t, h, "POST", "/system/endpoints/1/FOO", api.PostSystemMethodRequest{}, 400)
+	call(t, h, "GET", "/system/endpoints/1/FOO", nil, 404)
+
+	if e := endpoint(t, h, "1"); e.Path != "/users" {
+		t.Errorf("expected /users to be registered, got %s", e.Path)
+	}
+}
+
+func TestServeMockedEndpoint(t *testing.T) {
+	h := serve(t)
+	mocked(t, h, "users", api.PostSystemMethodRequest{})
+
+	if w := call(t, h, "GET", "/users", nil, 200); w.Body.String() != "mocked" {
+		t.Errorf("expected the example to be served, got %s", w.Body.String())
+	}
+
+	call(t, h, "DELETE", "/system/endpoints/1", nil, 200)
+	call(t, h, "GET", "/users", nil, 404)
+}
+
+func TestValidateQuery(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/properties", api.PostSystemPropertyRequest{
+		Type:       "integer",
+		Properties: map[string]string{"maximum": "10"},
+	}, 200)
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{
+		Name:       "limit",
+		Type:       "integer",
+		Properties: 1,
+	}, 200)
+	mocked(t, h, "users", api.PostSystemMethodRequest{Query: 1})
+
+	call(t, h, "GET", "/users?limit=5", nil, 200)
+	call(t, h, "GET", "/users?limit=20", nil, 400)
+	call(t, h, "GET", "/users?limit=many", nil, 400)
+}
+
+func TestValidateBodyTooLarge(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{Name: "name", Type: "string"}, 200)
+	call(t, h, "POST", "/system/endpoints", "users", 200)
+	call(t, h, "POST", "/system/endpoints/1/POST", api.PostSystemMethodRequest{Body: 1}, 200)
+	call(t, h, "POST", "/system/endpoints/1/POST/examples/default", api.PostSystemExampleRequest{Body: "mocked"}, 200)
+
+	call(t, h, "POST", "/users", map[string]any{"name": "ada"}, 200)
+	call(t, h, "POST", "/users", map[string]any{"name": 1}, 400)
+	call(t, h, "POST", "/users", map[string]any{"name": strings.Repeat("a", maxBody)}, 413)
+}
+
+func TestSlowBodyHoldsUpNoWriter(t *testing.T) {
+	h := serve(t)
+
+	body, stalled := io.Pipe()
+	defer stalled.Close()
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/system/import", body))
+	stalled.Write([]byte(`{"endpoints": {`))
+
+	var registered = make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/system/endpoints", strings.NewReader(`"users"`)))
+		registered <- w.Code
+	}()
+
+	select {
+	case status := <-registered:
+		if status != 200 {
+			t.Errorf("expected the endpoint to be registered, got %v", status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the endpoint to be registered while the import is still being sent")
+	}
+}
+
+func TestPatchEndpoint(t *testing.T) {
+	h := serve(t)
+	mocked(t, h, "users", api.PostSystemMethodRequest{})
+
+	path := "/people"
+	call(t, h, "PATCH", "/system/endpoints/1", api.PatchSystemEndpointRequest{Path: &path}, 200)
+
+	call(t, h, "GET", "/people", nil, 200)
+	call(t, h, "GET", "/users", nil, 404)
+}
+
+func TestDeleteLastMethod(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/endpoints", "users", 200)
+	call(t, h, "POST", "/system/endpoints/1/GET", api.PostSystemMethodRequest{}, 200)
+	call(t, h, "DELETE", "/system/endpoints/1/GET", nil, 200)
+
+	if e := endpoint(t, h, "1"); e.Methods != 0 {
+		t.Errorf("expected the emptied method group %v to be detached", e.Methods)
+	}
+
+	// a method added afterwards starts a group of its own
+	call(t, h, "POST", "/system/endpoints/1/POST", api.PostSystemMethodRequest{}, 200)
+	if e := endpoint(t, h, "1"); e.Methods == 0 {
+		t.Error("expected a new method group to be attached")
+	}
+}
+
+func TestUpdateKeepsDetachedGroups(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{Name: "limit", Type: "integer"}, 200)
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{Name: "offset", Type: "integer"}, 200)
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{Name: "id", Type: "integer"}, 200)
+	call(t, h, "POST", "/system/endpoints", "users/{id}", 200)
+	call(t, h, "POST", "/system/endpoints/1/GET", api.PostSystemMethodRequest{Query: 1}, 200)
+
+	query := 2
+	call(t, h, "PATCH", "/system/endpoints/1/GET", api.PatchSystemMethodRequest{Query: &query}, 200)
+	call(t, h, "GET", "/system/parameters/1", nil, 200)
+
+	uriParams := 3
+	call(t, h, "PATCH", "/system/endpoints/1", api.PatchSystemEndpointRequest{UriParams: &uriParams}, 200)
+	call(t, h, "PUT", "/system/endpoints/1", api.PutSystemEndpointRequest{Path: "users/{id}"}, 200)
+	call(t, h, "GET", "/system/parameters/3", nil, 200)
+	call(t, h, "GET", "/system/parameters/2", nil, 200)
+
+	// the method group the PUT let go of can be attached again
+	methods := 1
+	call(t, h, "PATCH", "/system/endpoints/1", api.PatchSystemEndpointRequest{Methods: &methods}, 200)
+	if e := endpoint(t, h, "1"); e.Configured.(map[string]any)["GET"] == nil {
+		t.Error("expected the GET method to be kept along with its group")
+	}
+}
+
+func TestDeleteLastParameterOfAttachedGroup(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{Name: "id", Type: "integer"}, 200)
+	call(t, h, "POST", "/system/endpoints", "users/{id}", 200)
+
+	group := 1
+	call(t, h, "PATCH", "/system/endpoints/1", api.PatchSystemEndpointRequest{UriParams: &group}, 200)
+
+	call(t, h, "DELETE", "/system/parameters/1/id", nil, 400)
+	call(t, h, "GET", "/system/parameters/1", nil, 200)
+}
+
+func TestDeletePropertyChecksParameters(t *testing.T) {
+	h := serve(t)
+
+	call(t, h, "POST", "/system/properties", api.PostSystemPropertyRequest{
+		Type:       "array",
+		Properties: map[string]string{"items": "integer", "maximum": "5"},
+	}, 200)
+	call(t, h, "POST", "/system/parameters", api.PostSystemParameterRequest{
+		Name:       "ids",
+		Type:       "array",
+		Properties: 1,
+	}, 200)
+
+	// the array parameter would be left without items
+	call(t, h, "DELETE", "/system/properties/1/items", nil, 400)
+	call(t, h, "DELETE", "/system/properties/1/maximum", nil, 200)
+}
+
+func TestConflictingPath(t *testing.T) {
+	h := serve(t)
+	mocked(t, h, "users/{id}", api.PostSystemMethodRequest{})
+
+	call(t, h, "POST", "/system/endpoints", "users/{uid}", 409)
+	call(t, h, "POST", "/system/endpoints", "users/{id", 400)
+	call(t, h, "POST", "/system/endpoints", "users/{a}/{a}", 400)
+	call(t, h, "POST", "/system/endpoints", "users/*/posts", 400)
+
+	call(t, h, "POST", "/system/endpoints", "accounts", 200)
+	path := "/users/{uid}"
+	call(t, h, "PATCH", "/system/endpoints/2", api.PatchSystemEndpointRequest{Path: &path}, 409)
+
+	// nothing was stored and the catalog is still served
+	call(t, h, "GET", "/users/1", nil, 200)
+	if e := endpoint(t, h, "2"); e.Path != "/accounts" {
+		t.Errorf("expected /accounts to be left in place, got %s", e.Path)
+	}
+}
+
+func TestCheckDocument(t *testing.T) {
+	h := serve(t)
+
+	var catalog = []byte(`
+endpoints:
+  /users/{id}:
+    uriParams: user
+  /users/{uid}: {}
+  /orders:
+    methods:
+      GET:
+        query: missing
+parameters:
+  user:
+    id:
+      type: integer
+      properties: bounds
+properties:
+  bounds:
+    maxLength: "3"
+`)
+
+	w := call(t, h, "POST", "/system/catalog/plan", catalog, 400)
+	for _, problem := range []string{
+		"parameter group missing is not defined",
+		"id of user: maxLength not allowed for integer parameters",
+		"paths conflict",
+	} {
+		if !strings.Contains(w.Body.String(), problem) {
+			t.Errorf("expected %q to be reported, got %s", problem, w.Body.String())
+		}
+	}
+
+	call(t, h, "POST", "/system/catalog/apply", catalog, 400)
+	call(t, h, "POST", "/system/import", []byte(`{"endpoints": {"/a/{id": {}}}`), 400)
+	call(t, h, "POST", "/system/import", bytes.Repeat([]byte(" "), maxDocument+1), 413)
+}
+
+func TestApplyCatalog(t *testing.T) {
+	h := serve(t)
+
+	var catalog = []byte(`
+endpoints:
+  /users:
+    methods:
+      GET:
+        examples:
+          default:
+            body: mocked
+`)
+
+	call(t, h, "POST", "/system/catalog/apply", catalog, 200)
+	call(t, h, "GET", "/users", nil, 200)
+
+	var report api.PostSystemCatalogReport
+	json.NewDecoder(call(t, h, "POST", "/system/catalog/plan", catalog, 200).Body).Decode(&report)
+	if len(report.Changes) != 0 {
+		t.Errorf("expected no changes once applied, got %v", report.Changes)
+	}
+}
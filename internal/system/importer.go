@@ -0,0 +1,445 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+)
+
+// ignored lists the header parameters OpenAPI describes but does not validate
+var ignored = []string{"accept", "content-type", "authorization"}
+
+// unsupported lists the schema keywords that have no property equivalent
+var unsupported = []string{
+	"oneOf", "anyOf", "allOf", "not", "const",
+	"minItems", "maxItems", "uniqueItems",
+	"minProperties", "maxProperties", "additionalProperties", "patternProperties",
+}
+
+// importer converts an OpenAPI 3.x document into a catalog document,
+// recording whatever cannot be represented instead of failing on it
+type importer struct {
+	source      map[string]any
+	document    document
+	unsupported []string
+}
+
+func (i *importer) skip(format string, args ...any) {
+	i.unsupported = append(i.unsupported, fmt.Sprintf(format, args...))
+}
+
+// resolve follows a local reference, returning the component name
+// alongside its definition
+func (i *importer) resolve(node map[string]any) (map[string]any, string) {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node, ""
+	}
+
+	var current any = i.source
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, _ := current.(map[string]any)
+		current = object[strings.NewReplacer("~1", "/", "~0", "~").Replace(key)]
+	}
+
+	resolved, ok := current.(map[string]any)
+	if !strings.HasPrefix(ref, "#/") || !ok {
+		i.skip("reference %s cannot be resolved", ref)
+		return nil, ""
+	}
+
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	return resolved, name
+}
+
+// group stores the parameters of a group, leaving out empty ones
+func (i *importer) group(name string, params map[string]documentParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	i.document.Parameters[name] = params
+	return name
+}
+
+// object converts an object schema into the parameter group called name
+func (i *importer) object(name string, schema map[string]any) string {
+	if _, ok := i.document.Parameters[name]; ok {
+		return name
+	}
+
+	// claimed up front so that recursive schemas refer back to the group
+	i.document.Parameters[name] = nil
+
+	var params = make(map[string]documentParameter)
+	var required, _ = schema["required"].([]any)
+
+	properties, _ := schema["properties"].(map[string]any)
+	for _, property := range slices.Sorted(maps.Keys(properties)) {
+		node, _ := properties[property].(map[string]any)
+		p, ok := i.parameter(name+" "+property, node)
+		if !ok {
+			continue
+		}
+
+		p.Required = slices.Contains(required, any(property))
+		params[property] = p
+	}
+
+	if len(params) == 0 {
+		delete(i.document.Parameters, name)
+		i.skip("schema %s has no properties", name)
+		return ""
+	}
+
+	return i.group(name, params)
+}
+
+// parameter converts a schema into a parameter, its constraints forming
+// a property group named after the context
+func (i *importer) parameter(context string, node map[string]any) (documentParameter, bool) {
+	schema, component := i.resolve(node)
+	if schema == nil {
+		return documentParameter{}, false
+	}
+
+	for _, keyword := range unsupported {
+		if _, ok := schema[keyword]; ok {
+			i.skip("%s of %s", keyword, context)
+			if slices.Contains([]string{"oneOf", "anyOf", "allOf", "not"}, keyword) {
+				return documentParameter{}, false
+			}
+		}
+	}
+
+	typ, ok := i.typeOf(context, schema)
+	if !ok {
+		return documentParameter{}, false
+	}
+
+	var props = make(map[string]string)
+
+	switch typ {
+	case "object":
+		name := component
+		if name == "" {
+			name = context
+		}
+		if props["schema"] = i.object(name, schema); props["schema"] == "" {
+			return documentParameter{}, false
+		}
+
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		item, ok := i.parameter(context+" items", items)
+		if !ok {
+			return documentParameter{}, false
+		}
+		if item.Type == "array" {
+			i.skip("nested arrays of %s", context)
+			return documentParameter{}, false
+		}
+
+		props = maps.Clone(i.document.Properties[item.Properties])
+		if props == nil {
+			props = make(map[string]string)
+		}
+		delete(i.document.Properties, item.Properties)
+		props["items"] = item.Type
+	}
+
+	i.constraints(context, typ, schema, props)
+
+	if len(props) == 0 {
+		return documentParameter{Type: typ}, true
+	}
+
+	i.document.Properties[context] = props
+	return documentParameter{Type: typ, Properties: context}, true
+}
+
+// typeOf maps the type and format of a schema onto a parameter type
+func (i *importer) typeOf(context string, schema map[string]any) (string, bool) {
+	var typ, _ = schema["type"].(string)
+
+	// OpenAPI 3.1 allows a list of types, of which only nullability is ignored
+	if list, ok := schema["type"].([]any); ok {
+		for _, t := range list {
+			if t != "null" {
+				if typ != "" {
+					i.skip("multiple types of %s", context)
+					return "", false
+				}
+				typ, _ = t.(string)
+			}
+		}
+	}
+
+	if typ == "" {
+		if _, ok := schema["properties"]; ok {
+			typ = "object"
+		} else {
+			typ = "string"
+		}
+	}
+
+	if typ == "string" {
+		format, _ := schema["format"].(string)
+		for name, f := range formats {
+			if f == format {
+				typ = name
+			}
+		}
+	}
+
+	if !slices.Contains(types, typ) {
+		i.skip("type %s of %s", typ, context)
+		return "", false
+	}
+
+	return typ, true
+}
+
+// constraints copies the validation keywords of a schema into props,
+// leaving out those the parameter type does not honour
+func (i *importer) constraints(context, typ string, schema map[string]any, props map[string]string) {
+	if values, ok := schema["enum"].([]any); ok {
+		var enum []string
+		for _, v := range values {
+			enum = append(enum, fmt.Sprint(v))
+		}
+
+		if joined := strings.Join(enum, ","); strings.Count(joined, ",") != len(enum)-1 {
+			i.skip("enum of %s holds values containing commas", context)
+		} else {
+			props["enum"] = joined
+		}
+	}
+
+	for _, name := range []string{"minimum", "maximum", "multipleOf", "minLength", "maxLength", "pattern"} {
+		if value, ok := schema[name]; ok {
+			props[name] = fmt.Sprint(value)
+		}
+	}
+
+	// OpenAPI 3.0 marks the bounds as exclusive where 3.1 holds the bound itself
+	for _, name := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		bound := strings.ToLower(strings.TrimPrefix(name, "exclusive"))
+		switch value := schema[name].(type) {
+		case nil:
+		case bool:
+			if value && props[bound] != "" {
+				props[name] = props[bound]
+				delete(props, bound)
+			}
+		default:
+			props[name] = fmt.Sprint(value)
+		}
+	}
+
+	for _, name := range unknownProperties(typ, props) {
+		i.skip("%s of %s parameter %s", name, typ, context)
+		delete(props, name)
+	}
+}
+
+// parameters converts the parameters found at location into a group
+func (i *importer) parameters(name, location string, nodes ...[]any) string {
+	var params = make(map[string]documentParameter)
+
+	for _, list := range nodes {
+		for _, entry := range list {
+			node, _ := entry.(map[string]any)
+			if node, _ = i.resolve(node); node == nil {
+				continue
+			}
+
+			in, _ := node["in"].(string)
+			parameter, _ := node["name"].(string)
+			if in == "cookie" && location == "query" {
+				i.skip("cookie parameter %s of %s", parameter, name)
+			}
+			if in != location || (in == "header" && slices.Contains(ignored, strings.ToLower(parameter))) {
+				continue
+			}
+
+			schema, ok := node["schema"].(map[string]any)
+			if !ok {
+				i.skip("parameter %s of %s has no schema", parameter, name)
+				continue
+			}
+
+			p, ok := i.parameter(name+" "+parameter, schema)
+			if !ok {
+				continue
+			}
+			p.Required, _ = node["required"].(bool)
+			params[parameter] = p
+		}
+	}
+
+	return i.group(name, params)
+}
+
+// body converts the JSON request body of an operation into a group
+func (i *importer) body(name string, node map[string]any) string {
+	if node, _ = i.resolve(node); node == nil {
+		return ""
+	}
+
+	content, _ := node["content"].(map[string]any)
+	media, ok := content["application/json"].(map[string]any)
+	if !ok {
+		for _, media := range slices.Sorted(maps.Keys(content)) {
+			i.skip("%s body of %s", media, name)
+		}
+		return ""
+	}
+
+	schema, _ := media["schema"].(map[string]any)
+	resolved, component := i.resolve(schema)
+	if resolved == nil {
+		return ""
+	}
+
+	if typ, _ := i.typeOf(name, resolved); typ != "object" {
+		i.skip("%s body of %s is not an object", typ, name)
+		return ""
+	}
+
+	if component != "" {
+		name = component
+	}
+	return i.object(name, resolved)
+}
+
+// convert walks the paths of the source document
+func (i *importer) convert() {
+	i.document = document{
+		Endpoints:  make(map[string]documentEndpoint),
+		Parameters: make(map[string]map[string]documentParameter),
+		Properties: make(map[string]map[string]string),
+	}
+
+	paths, _ := i.source["paths"].(map[string]any)
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item, _ := paths[path].(map[string]any)
+		if item, _ = i.resolve(item); item == nil {
+			continue
+		}
+
+		var endpoint = documentEndpoint{Methods: make(map[string]documentMethod)}
+		var shared, _ = item["parameters"].([]any)
+		var declared [][]any
+
+		for _, key := range slices.Sorted(maps.Keys(item)) {
+			operation, ok := item[key].(map[string]any)
+			if !ok || key == "parameters" {
+				continue
+			}
+
+			verb := strings.ToUpper(key)
+			if _, ok := handlers[verb]; !ok {
+				i.skip("%s operation of %s", verb, path)
+				continue
+			}
+
+			own, _ := operation["parameters"].([]any)
+			declared = append(declared, own)
+
+			var method documentMethod
+			method.Query = i.parameters(verb+" "+path+" query", "query", shared, own)
+			method.Headers = i.parameters(verb+" "+path+" headers", "header", shared, own)
+			if body, ok := operation["requestBody"].(map[string]any); ok {
+				method.Body = i.body(verb+" "+path+" body", body)
+			}
+			endpoint.Methods[verb] = method
+		}
+
+		endpoint.UriParams = i.parameters(path+" uri", "path", append([][]any{shared}, declared...)...)
+		i.document.Endpoints[normalize(path)] = endpoint
+	}
+}
+
+func PostSystemOpenApi(w http.ResponseWriter, r *http.Request) {
+	var dryRun = r.URL.Query().Get("dryRun") == "true"
+	var source map[string]any
+
+	if !parseRequest(w, r, "OpenAPI document", &source) {
+		return
+	}
+
+	if version, _ := source["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		message := "only OpenAPI 3.x documents can be imported"
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	var i = importer{source: source}
+	i.convert()
+
+	// references shared between operations are reported once
+	slices.Sort(i.unsupported)
+	i.unsupported = slices.Compact(i.unsupported)
+
+	var report = api.PostSystemOpenApiReport{
+		DryRun:      dryRun,
+		Created:     i.document.summary(),
+		Unsupported: i.unsupported,
+	}
+	var problems = i.document.check(r.Context())
+
+	registry, done := edit()
+	defer done()
+
+	for _, e := range registry.endpoints {
+		if _, ok := i.document.Endpoints[e.Path]; ok {
+			report.Conflicts = append(report.Conflicts, e.Path+" is already registered")
+		}
+	}
+	slices.Sort(report.Conflicts)
+	report.Conflicts = append(report.Conflicts, problems...)
+
+	if len(report.Conflicts) == 0 {
+		paths := append(registry.paths(0), slices.Collect(maps.Keys(i.document.Endpoints))...)
+		if err := checkRoutes(paths...); err != nil {
+			report.Conflicts = append(report.Conflicts, "paths conflict with the registered endpoints: "+err.Error())
+		}
+	}
+
+	if dryRun {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	if len(report.Conflicts) != 0 {
+		message := "OpenAPI document could not be imported: %s"
+		message = util.Message(message, strings.Join(report.Conflicts, ", "))
+		api.ConflictErrorHandler(w, r, message)
+		return
+	}
+
+	var written rows
+	err := store.transaction(r.Context(), func(tx writer) (err error) {
+		written, err = install(tx, i.document, nil)
+		return err
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry.merge(written)
+	publish(registry)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
@@ -1,6 +1,11 @@
 package system
 
 import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
 	"Factory/internal/util"
 
 	"github.com/go-chi/chi"
@@ -16,71 +21,199 @@ type _registry struct {
 	methods    map[int]map[string]_method    // methods    >> [id] --> [verb] --> _method
 	parameters map[int]map[string]_parameter // parameters >> [id] --> [name] --> _parameter
 	properties map[int]map[string]string     // properties >> [id] --> map of _property name,value pairs
+	examples   map[int]map[string]_example   // examples   >> [id] --> [name] --> _example
+	upstreams  map[int]_upstream             // upstreams  >> [id] --> _upstream
 }
 
-var registry = _registry{
-	endpoints:  make(map[int]_endpoint),
-	methods:    make(map[int]map[string]_method),
-	parameters: make(map[int]map[string]_parameter),
-	properties: make(map[int]map[string]string),
+// published is the registry the catalog is served from; it is never changed
+// in place, writers publishing a changed copy of it instead so that readers
+// always see the endpoints, methods, parameters and properties of one version
+var published atomic.Pointer[_registry]
+
+// writing serializes writers so that none publishes over the changes of another
+var writing sync.Mutex
+
+// current returns the published registry, to be read only
+func current() _registry {
+	return *published.Load()
 }
 
-func Initialize(r *chi.Mux) {
-	loadEndpoints()
-	loadMethods()
-	loadParameters()
-	loadProperties()
+// edit returns a copy of the registry to be changed and published,
+// other writers waiting until the returned function is called; the
+// request body is read beforehand so that slow clients hold up no one
+func edit() (_registry, func()) {
+	writing.Lock()
+	return current().clone(), writing.Unlock
+}
 
-	catalog(r)
-	system(r)
+// publish replaces the registry and rebuilds the routes served from it
+func publish(registry _registry) {
+	published.Store(&registry)
+	routes.rebuild(registry)
 }
 
-func load[T any](table string, processor func(T)) {
-	var db = util.Database
-	var element T
-
-	stmt := "SELECT * FROM " + table
-	if rows, err := db.Query(stmt); err == nil {
-		defer rows.Close()
-		_ = db.ForEach(rows, &element, func() error {
-			processor(element)
-			return nil
-		})
-	} else {
-		message := "failed to fetch %ss from system: %s"
-		panic(util.Message(message, table, err.Error()))
+func blank() _registry {
+	return _registry{
+		endpoints:  make(map[int]_endpoint),
+		methods:    make(map[int]map[string]_method),
+		parameters: make(map[int]map[string]_parameter),
+		properties: make(map[int]map[string]string),
+		examples:   make(map[int]map[string]_example),
+		upstreams:  make(map[int]_upstream),
 	}
 }
 
-func loadEndpoints() {
-	load[_endpoint]("endpoint", func(e _endpoint) {
-		registry.endpoints[e.id] = e
-	})
+// Initialize loads the registry from the storage of the given kind,
+// postgres by default, memory or json, and serves the catalog
+func Initialize(r *chi.Mux, kind string, file string) {
+	switch kind {
+	case "", "postgres":
+		store = postgres{}
+	case "memory":
+		store = &memory{}
+	case "json":
+		if file == "" {
+			panic("a file must be provided for the json storage")
+		}
+		store = &memory{file: file}
+	default:
+		panic(util.Message("unknown storage %s, expected postgres, memory or json", kind))
+	}
+
+	loaded, err := store.load()
+	if err != nil {
+		message := "failed to load the catalog: %s"
+		panic(util.Message(message, err.Error()))
+	}
+	publish(loaded)
+
+	r.NotFound(routes.ServeHTTP)
+	system(r)
 }
 
-func loadMethods() {
-	load[_method]("method", func(m _method) {
-		if registry.methods[m.id] == nil {
-			registry.methods[m.id] = make(map[string]_method)
+// add places a row of any catalog table in the registry
+func (registry *_registry) add(row any) {
+	switch row := row.(type) {
+	case _endpoint:
+		registry.endpoints[row.Id] = row
+	case _method:
+		if registry.methods[row.Id] == nil {
+			registry.methods[row.Id] = make(map[string]_method)
 		}
-		registry.methods[m.id][m.name] = m
-	})
+		registry.methods[row.Id][row.Name] = row
+	case _parameter:
+		if registry.parameters[row.Id] == nil {
+			registry.parameters[row.Id] = make(map[string]_parameter)
+		}
+		registry.parameters[row.Id][row.Name] = row
+	case _property:
+		if registry.properties[row.Id] == nil {
+			registry.properties[row.Id] = make(map[string]string)
+		}
+		registry.properties[row.Id][row.Name] = row.Value
+	case _example:
+		if registry.examples[row.Id] == nil {
+			registry.examples[row.Id] = make(map[string]_example)
+		}
+		registry.examples[row.Id][row.Name] = row
+	case _upstream:
+		registry.upstreams[row.Id] = row
+	}
 }
 
-func loadParameters() {
-	load[_parameter]("parameter", func(p _parameter) {
-		if registry.parameters[p.id] == nil {
-			registry.parameters[p.id] = make(map[string]_parameter)
-		}
-		registry.parameters[p.id][p.name] = p
+// clone copies the registry, groups included
+func (registry _registry) clone() _registry {
+	var copied = blank()
+
+	maps.Copy(copied.endpoints, registry.endpoints)
+	maps.Copy(copied.upstreams, registry.upstreams)
+	for id, group := range registry.methods {
+		copied.methods[id] = maps.Clone(group)
+	}
+	for id, group := range registry.parameters {
+		copied.parameters[id] = maps.Clone(group)
+	}
+	for id, group := range registry.properties {
+		copied.properties[id] = maps.Clone(group)
+	}
+	for id, group := range registry.examples {
+		copied.examples[id] = maps.Clone(group)
+	}
+
+	return copied
+}
+
+// replace takes the rows of a table from another registry
+func (registry *_registry) replace(from _registry, table string) {
+	switch table {
+	case "endpoint":
+		registry.endpoints = from.endpoints
+	case "method":
+		registry.methods = from.methods
+	case "parameter":
+		registry.parameters = from.parameters
+	case "property":
+		registry.properties = from.properties
+	case "example":
+		registry.examples = from.examples
+	case "upstream":
+		registry.upstreams = from.upstreams
+	}
+}
+
+// contains reports whether the registry holds a row with the key of the given one
+func (registry _registry) contains(row any) bool {
+	var ok bool
+
+	switch row := row.(type) {
+	case _endpoint:
+		_, ok = registry.endpoints[row.Id]
+	case _method:
+		_, ok = registry.methods[row.Id][row.Name]
+	case _parameter:
+		_, ok = registry.parameters[row.Id][row.Name]
+	case _property:
+		_, ok = registry.properties[row.Id][row.Name]
+	case _example:
+		_, ok = registry.examples[row.Id][row.Name]
+	case _upstream:
+		_, ok = registry.upstreams[row.Id]
+	}
+
+	return ok
+}
+
+// dump lists the rows of the registry by table, ordered by id and name
+func (registry _registry) dump() map[string][]any {
+	var dumped = make(map[string][]any)
+	for _, table := range tables {
+		dumped[table] = []any{}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(registry.endpoints)) {
+		dumped["endpoint"] = append(dumped["endpoint"], registry.endpoints[id])
+	}
+	for _, id := range slices.Sorted(maps.Keys(registry.upstreams)) {
+		dumped["upstream"] = append(dumped["upstream"], registry.upstreams[id])
+	}
+	dumped["method"] = dumpGroups(registry.methods, func(_ int, _ string, m _method) any { return m })
+	dumped["parameter"] = dumpGroups(registry.parameters, func(_ int, _ string, p _parameter) any { return p })
+	dumped["property"] = dumpGroups(registry.properties, func(id int, name string, value string) any {
+		return _property{id, name, value}
 	})
+	dumped["example"] = dumpGroups(registry.examples, func(_ int, _ string, x _example) any { return x })
+
+	return dumped
 }
 
-func loadProperties() {
-	load[_property]("property", func(p _property) {
-		if registry.properties[p.id] == nil {
-			registry.properties[p.id] = make(map[string]string)
+func dumpGroups[T any](groups map[int]map[string]T, row func(int, string, T) any) []any {
+	var dumped = []any{}
+
+	for _, id := range slices.Sorted(maps.Keys(groups)) {
+		for _, name := range slices.Sorted(maps.Keys(groups[id])) {
+			dumped = append(dumped, row(id, name, groups[id][name]))
 		}
-		registry.properties[p.id][p.name] = p.value
-	})
+	}
+
+	return dumped
 }
@@ -0,0 +1,221 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"Factory/internal/util"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// memory keeps the catalog in the process, lost on exit unless
+// a file is given in which case it is saved there as JSON
+type memory struct {
+	mutex     sync.Mutex
+	tables    _registry
+	sequences map[string]int
+	file      string
+}
+
+func (m *memory) load() (_registry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tables = blank()
+	m.sequences = make(map[string]int)
+
+	if m.file != "" {
+		content, err := os.ReadFile(m.file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return blank(), err
+		}
+
+		if len(content) != 0 {
+			var saved map[string][]json.RawMessage
+			if err := json.Unmarshal(content, &saved); err != nil {
+				message := "failed to read %s: %s"
+				return blank(), errors.New(util.Message(message, m.file, err.Error()))
+			}
+
+			for _, table := range tables {
+				for _, raw := range saved[table] {
+					row := rowOf(table)
+					if err := json.Unmarshal(raw, row); err != nil {
+						message := "failed to read a %s from %s: %s"
+						return blank(), errors.New(util.Message(message, table, m.file, err.Error()))
+					}
+					m.tables.add(deref(row))
+					m.sequences[table] = max(m.sequences[table], *identify(row))
+				}
+			}
+		}
+	}
+
+	return m.tables.clone(), nil
+}
+
+func (*memory) bindable(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+// transaction runs method against a copy of the tables
+// which replaces them, and the file, once it succeeds
+func (m *memory) transaction(ctx context.Context, method func(tx writer) error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var tx = &changes{m.tables.clone(), make(map[string]int)}
+	for table, sequence := range m.sequences {
+		tx.sequences[table] = sequence
+	}
+
+	if err := method(tx); err != nil {
+		return err
+	}
+
+	if m.file != "" {
+		if err := save(m.file, tx.tables); err != nil {
+			return err
+		}
+	}
+
+	m.tables = tx.tables
+	m.sequences = tx.sequences
+	return nil
+}
+
+// save writes the tables to a file, replacing it
+// in one go so that a failure leaves the former
+func save(file string, tables _registry) error {
+	content, err := json.MarshalIndent(tables.dump(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	if _, err := temporary.Write(content); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary.Name(), file)
+}
+
+// changes writes rows to the copied tables of a memory transaction
+type changes struct {
+	tables    _registry
+	sequences map[string]int
+}
+
+// duplicate is the error of a database refusing a row whose key is taken
+func duplicate(table string) error {
+	message := "duplicate key value violates unique constraint on %s"
+	return &pgconn.PgError{Code: "23505", Message: util.Message(message, table)}
+}
+
+func (c *changes) allocate(table string) (int, error) {
+	c.sequences[table]++
+	return c.sequences[table], nil
+}
+
+func (c *changes) insert(row any) error {
+	var table = tableOf(row)
+	var id = identify(row)
+
+	if *id == 0 {
+		*id, _ = c.allocate(table)
+	}
+	c.sequences[table] = max(c.sequences[table], *id)
+
+	if c.tables.contains(deref(row)) {
+		return duplicate(table)
+	}
+	if e, ok := row.(*_endpoint); ok {
+		for _, other := range c.tables.endpoints {
+			if other.Path == e.Path {
+				return duplicate(table)
+			}
+		}
+	}
+
+	c.tables.add(deref(row))
+	return nil
+}
+
+func (c *changes) update(row any) error {
+	if c.tables.contains(deref(row)) {
+		c.tables.add(deref(row))
+	}
+	return nil
+}
+
+func (c *changes) remove(table string, id int, name string) error {
+	var reg = &c.tables
+
+	switch table {
+	case "endpoint":
+		delete(reg.endpoints, id)
+	case "upstream":
+		delete(reg.upstreams, id)
+	case "method":
+		removeFrom(reg.methods, id, name)
+	case "parameter":
+		removeFrom(reg.parameters, id, name)
+	case "property":
+		removeFrom(reg.properties, id, name)
+	case "example":
+		removeFrom(reg.examples, id, name)
+	}
+	return nil
+}
+
+// removeFrom deletes a named row of a group, or the whole group without a name
+func removeFrom[T any](groups map[int]map[string]T, id int, name string) {
+	if name == "" {
+		delete(groups, id)
+		return
+	}
+
+	delete(groups[id], name)
+	if len(groups[id]) == 0 {
+		delete(groups, id)
+	}
+}
+
+func (c *changes) clear(table string) error {
+	var reg = &c.tables
+	var empty = blank()
+
+	switch table {
+	case "endpoint":
+		reg.endpoints = empty.endpoints
+	case "upstream":
+		reg.upstreams = empty.upstreams
+	case "method":
+		reg.methods = empty.methods
+	case "parameter":
+		reg.parameters = empty.parameters
+	case "property":
+		reg.properties = empty.properties
+	case "example":
+		reg.examples = empty.examples
+	}
+	return nil
+}
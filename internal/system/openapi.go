@@ -0,0 +1,359 @@
+package system
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+)
+
+// formats maps the string based parameter types to their OpenAPI format
+var formats = map[string]string{
+	"uuid":      "uuid",
+	"email":     "email",
+	"uri":       "uri",
+	"date":      "date",
+	"date-time": "date-time",
+	"duration":  "duration",
+}
+
+// segment matches the parameters of a chi route pattern, including regexps
+var segment = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// words matches the alphanumeric runs of a path used to name operations
+var words = regexp.MustCompile(`[A-Za-z0-9]+`)
+
+// specification renders the registry as an OpenAPI 3.1 document
+type specification struct {
+	registry _registry
+	schemas  map[string]JObject
+	pending  []int
+}
+
+func (s *specification) reference(group int) JObject {
+	name := "Parameters" + strconv.Itoa(group)
+	if _, ok := s.schemas[name]; !ok {
+		s.schemas[name] = nil
+		s.pending = append(s.pending, group)
+	}
+	return JObject{"$ref": "#/components/schemas/" + name}
+}
+
+// object describes a parameter group as an object schema
+func (s *specification) object(group int) JObject {
+	var properties = make(JObject)
+	var required []string
+
+	params := s.registry.parameters[group]
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		properties[name] = s.schema(params[name])
+		if params[name].Required {
+			required = append(required, name)
+		}
+	}
+
+	object := JObject{"type": "object", "properties": properties}
+	if len(required) != 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// schema describes the type and property group of a parameter
+func (s *specification) schema(p _parameter) JObject {
+	var props = s.registry.properties[p.Properties]
+	var schema = make(JObject)
+
+	switch p.Type {
+	case "array":
+		p.Type = props["items"]
+		return JObject{"type": "array", "items": s.schema(p)}
+
+	case "object":
+		group, _ := strconv.Atoi(props["schema"])
+		return s.reference(group)
+
+	case "integer", "number", "boolean", "string":
+		schema["type"] = p.Type
+
+	default:
+		schema["type"] = "string"
+		if format := formats[p.Type]; format != "" {
+			schema["format"] = format
+		}
+	}
+
+	var number = func(value string) any {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+		return value
+	}
+
+	for name, value := range props {
+		switch name {
+		case "enum":
+			var values []any
+			for _, v := range strings.Split(value, ",") {
+				if p.Type == "integer" || p.Type == "number" {
+					values = append(values, number(v))
+				} else if p.Type == "boolean" {
+					b, _ := strconv.ParseBool(v)
+					values = append(values, b)
+				} else {
+					values = append(values, v)
+				}
+			}
+			schema["enum"] = values
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf", "minLength", "maxLength":
+			schema[name] = number(value)
+		case "pattern":
+			schema[name] = value
+		}
+	}
+
+	return schema
+}
+
+func (s *specification) parameters(in string, group int, declared []string) []JObject {
+	var parameters []JObject
+
+	params := s.registry.parameters[group]
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		p := params[name]
+		parameter := JObject{
+			"name":     name,
+			"in":       in,
+			"required": p.Required || in == "path",
+			"schema":   s.schema(p),
+		}
+
+		// arrays are read from a single comma separated value,
+		// which queries only send when not exploded
+		if in == "query" && p.Type == "array" {
+			parameter["style"] = "form"
+			parameter["explode"] = false
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	// every segment of a path must be described even when not validated
+	for _, name := range declared {
+		if _, ok := params[name]; !ok {
+			parameters = append(parameters, JObject{
+				"name":     name,
+				"in":       in,
+				"required": true,
+				"schema":   JObject{"type": "string"},
+			})
+		}
+	}
+
+	return parameters
+}
+
+func (s *specification) operation(e _endpoint, m _method) JObject {
+	var id = strings.ToLower(m.Name)
+	for _, word := range words.FindAllString(segment.ReplaceAllString(e.Path, "$1"), -1) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	var operation = JObject{"operationId": id}
+
+	var parameters []JObject
+	parameters = append(parameters, s.parameters("header", m.Headers, nil)...)
+	parameters = append(parameters, s.parameters("query", m.Query, nil)...)
+	if len(parameters) != 0 {
+		operation["parameters"] = parameters
+	}
+
+	if m.Body != 0 {
+		operation["requestBody"] = JObject{
+			"required": true,
+			"content": JObject{
+				"application/json": JObject{"schema": s.reference(m.Body)},
+			},
+		}
+	}
+
+	var responses = JObject{
+		"400": JObject{"$ref": "#/components/responses/BadRequest"},
+		"500": JObject{"$ref": "#/components/responses/InternalError"},
+	}
+
+	if m.Body != 0 {
+		responses["413"] = JObject{"$ref": "#/components/responses/TooLarge"}
+	}
+
+	if examples, ok := s.registry.examples[m.Examples]; ok {
+		var byStatus = make(map[string]JObject)
+		for name, example := range examples {
+			status := strconv.Itoa(example.Status)
+			if example.Status == 0 {
+				status = strconv.Itoa(http.StatusOK)
+			}
+			if byStatus[status] == nil {
+				byStatus[status] = make(JObject)
+			}
+			byStatus[status][name] = JObject{"value": example.Body}
+		}
+
+		for status, named := range byStatus {
+			responses[status] = JObject{
+				"description": "Mocked response",
+				"content": JObject{
+					"application/json": JObject{"examples": named},
+				},
+			}
+		}
+	} else if e.Upstream != 0 {
+		responses["default"] = JObject{"description": "Response of the upstream service"}
+		responses["502"] = JObject{"$ref": "#/components/responses/BadGateway"}
+		responses["504"] = JObject{"$ref": "#/components/responses/GatewayTimeout"}
+	} else {
+		status := map[string]string{"POST": "201"}[m.Name]
+		if status == "" {
+			status = "200"
+		}
+		responses[status] = JObject{"description": "Successful response"}
+		if e.UriParams != 0 {
+			responses["404"] = JObject{"$ref": "#/components/responses/NotFound"}
+		}
+		if e.Table != "" && m.Name != "GET" && m.Name != "DELETE" {
+			responses["409"] = JObject{"$ref": "#/components/responses/Conflict"}
+			responses["422"] = JObject{"$ref": "#/components/responses/Unprocessable"}
+		}
+		if e.Table != "" {
+			responses["503"] = JObject{"$ref": "#/components/responses/Unavailable"}
+		}
+	}
+
+	operation["responses"] = responses
+	return operation
+}
+
+func (registry _registry) openapi() JObject {
+	var spec = specification{registry: registry, schemas: make(map[string]JObject)}
+	var paths = make(JObject)
+
+	for _, e := range registry.endpoints {
+		var declared []string
+		for _, match := range segment.FindAllStringSubmatch(e.Path, -1) {
+			declared = append(declared, match[1])
+		}
+
+		path := JObject{}
+		if parameters := spec.parameters("path", e.UriParams, declared); len(parameters) != 0 {
+			path["parameters"] = parameters
+		}
+
+		for verb, m := range registry.methods[e.Methods] {
+			path[strings.ToLower(verb)] = spec.operation(e, m)
+		}
+
+		paths[segment.ReplaceAllString(e.Path, "{$1}")] = path
+	}
+
+	// object schemas may reference further groups as they are described
+	for len(spec.pending) != 0 {
+		group := spec.pending[0]
+		spec.pending = spec.pending[1:]
+		spec.schemas["Parameters"+strconv.Itoa(group)] = spec.object(group)
+	}
+
+	var problem = func(description string) JObject {
+		return JObject{
+			"description": description,
+			"content": JObject{
+				"application/problem+json": JObject{"schema": JObject{"$ref": "#/components/schemas/Problem"}},
+				"application/json":         JObject{"schema": JObject{"$ref": "#/components/schemas/Error"}},
+			},
+		}
+	}
+
+	var violations = JObject{
+		"type": "array",
+		"items": JObject{
+			"type": "object",
+			"properties": JObject{
+				"location":  JObject{"type": "string"},
+				"parameter": JObject{"type": "string"},
+				"value":     JObject{"type": "string"},
+				"rule":      JObject{"type": "string"},
+				"message":   JObject{"type": "string"},
+			},
+		},
+	}
+
+	schemas := JObject{
+		"Problem": JObject{
+			"type": "object",
+			"properties": JObject{
+				"type":     JObject{"type": "string"},
+				"title":    JObject{"type": "string"},
+				"status":   JObject{"type": "integer"},
+				"detail":   JObject{"type": "string"},
+				"instance": JObject{"type": "string"},
+				"errors":   violations,
+			},
+		},
+		"Error": JObject{
+			"type": "object",
+			"properties": JObject{
+				"Id":      JObject{"type": "string"},
+				"Code":    JObject{"type": "integer"},
+				"Message": JObject{"type": "string"},
+				"Errors":  violations,
+			},
+		},
+	}
+	for name, schema := range spec.schemas {
+		schemas[name] = schema
+	}
+
+	return JObject{
+		"openapi": "3.1.0",
+		"info": JObject{
+			"title":   "Factory",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": JObject{
+			"schemas": schemas,
+			"responses": JObject{
+				"BadRequest":     problem("The request failed validation"),
+				"NotFound":       problem("The resource does not exist"),
+				"Conflict":       problem("The resource already exists"),
+				"TooLarge":       problem("The request body is too large"),
+				"Unprocessable":  problem("The resource references a resource that does not exist"),
+				"Unavailable":    problem("The database is unavailable"),
+				"InternalError":  problem("The request could not be processed"),
+				"BadGateway":     problem("The upstream service is unavailable"),
+				"GatewayTimeout": problem("The upstream service did not respond in time"),
+			},
+		},
+	}
+}
+
+func GetSystemOpenApiJson(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(current().openapi())
+}
+
+func GetSystemOpenApiYaml(w http.ResponseWriter, r *http.Request) {
+	document, err := util.YAML(current().openapi())
+	if err != nil {
+		util.GetLogger(r).Error(err)
+		api.InternalErrorHandler(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(document)
+}
@@ -0,0 +1,336 @@
+package system
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+
+	"github.com/go-chi/chi"
+)
+
+func (registry _registry) parameterGroupFrom(w http.ResponseWriter, r *http.Request) (int, map[string]_parameter, bool) {
+	parameter := chi.URLParam(r, "parameter")
+
+	id, err := isId(parameter)
+	if err != nil {
+		api.RequestErrorHandler(w, r, err.Error())
+		return 0, nil, false
+	}
+
+	params, ok := registry.parameters[id]
+	if !ok {
+		message := "no parameter group bears the id %s"
+		message = util.Message(message, parameter)
+		api.NotFoundErrorHandler(w, r, message)
+	}
+
+	return id, params, ok
+}
+
+// parametersExist verifies every non-zero group may be attached
+// to an endpoint or method as uri parameters, headers, query or body
+func (registry _registry) parametersExist(w http.ResponseWriter, r *http.Request, groups ...int) bool {
+	for _, group := range groups {
+		if _, ok := registry.parameters[group]; !ok && group != 0 {
+			message := "parameter group %v does not exist"
+			message = util.Message(message, group)
+			api.NotFoundErrorHandler(w, r, message)
+			return false
+		}
+	}
+	return true
+}
+
+// parametersReferenced reports whether any endpoint, method or
+// object schema property has the parameter group attached to it
+func (registry _registry) parametersReferenced(group int) bool {
+	for _, e := range registry.endpoints {
+		if e.UriParams == group {
+			return true
+		}
+	}
+	for _, methods := range registry.methods {
+		for _, m := range methods {
+			if m.Query == group || m.Headers == group || m.Body == group {
+				return true
+			}
+		}
+	}
+	for _, props := range registry.properties {
+		if props["schema"] == strconv.Itoa(group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (registry _registry) checkParameter(w http.ResponseWriter, r *http.Request, p _parameter) bool {
+	if !slices.Contains(types, p.Type) {
+		message := "%s (%s) must be in (%s)"
+		message = util.Message(message, "type", p.Type, strings.Join(types, ","))
+		api.RequestErrorHandler(w, r, message)
+		return false
+	}
+
+	props, ok := registry.properties[p.Properties]
+	if !ok && p.Properties != 0 {
+		message := "property group %v does not exist"
+		message = util.Message(message, p.Properties)
+		api.NotFoundErrorHandler(w, r, message)
+		return false
+	}
+
+	return registry.checkProperties(w, r, p.Type, props)
+}
+
+// saveParameter replaces the stored definition of an existing parameter
+func (registry _registry) saveParameter(w http.ResponseWriter, r *http.Request, p _parameter) bool {
+	if !registry.checkParameter(w, r, p) {
+		return false
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.update(&p)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return false
+	}
+
+	registry.parameters[p.Id][p.Name] = p
+	publish(registry)
+	return true
+}
+
+func PostSystemParameterGroup(w http.ResponseWriter, r *http.Request) {
+	var parameter api.PostSystemParameterRequest
+	json.NewDecoder(r.Body).Decode(&parameter)
+
+	registry, done := edit()
+	defer done()
+
+	if parameter.Name == "" {
+		message := "parameter name must be provided"
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	p := _parameter{
+		Name:       parameter.Name,
+		Type:       parameter.Type,
+		Required:   parameter.Required,
+		Properties: parameter.Properties,
+	}
+
+	if !registry.checkParameter(w, r, p) {
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.insert(&p)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry.parameters[p.Id] = map[string]_parameter{p.Name: p}
+	publish(registry)
+
+	message := "Successfully registered parameter group %v with %s"
+	message = util.Message(message, p.Id, p.Name)
+	api.SuccessfulSystemPost(w, r, message)
+}
+
+func PostSystemParameter(w http.ResponseWriter, r *http.Request) {
+	var parameter api.PostSystemParameterRequest
+	json.NewDecoder(r.Body).Decode(&parameter)
+
+	registry, done := edit()
+	defer done()
+
+	id, params, ok := registry.parameterGroupFrom(w, r)
+	if !ok {
+		return
+	}
+
+	parameter.Name = chi.URLParam(r, "name")
+
+	if _, ok = params[parameter.Name]; ok {
+		message := "%s is already registered for parameter group %v"
+		message = util.Message(message, parameter.Name, id)
+		api.ConflictErrorHandler(w, r, message)
+		return
+	}
+
+	p := _parameter{
+		Id:         id,
+		Name:       parameter.Name,
+		Type:       parameter.Type,
+		Required:   parameter.Required,
+		Properties: parameter.Properties,
+	}
+
+	if !registry.checkParameter(w, r, p) {
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.insert(&p)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry.parameters[id][p.Name] = p
+	publish(registry)
+
+	message := "Successfully registered parameter %s for parameter group %v"
+	message = util.Message(message, p.Name, id)
+	api.SuccessfulSystemPost(w, r, message)
+}
+
+func (registry _registry) parameterFrom(w http.ResponseWriter, r *http.Request) (_parameter, bool) {
+	id, params, ok := registry.parameterGroupFrom(w, r)
+	if !ok {
+		return _parameter{}, false
+	}
+
+	name := chi.URLParam(r, "name")
+	p, ok := params[name]
+	if !ok {
+		message := "%s is not defined for parameter group %v"
+		message = util.Message(message, name, id)
+		api.NotFoundErrorHandler(w, r, message)
+	}
+
+	return p, ok
+}
+
+func PutSystemParameter(w http.ResponseWriter, r *http.Request) {
+	var parameter api.PutSystemParameterRequest
+	json.NewDecoder(r.Body).Decode(&parameter)
+
+	registry, done := edit()
+	defer done()
+
+	p, ok := registry.parameterFrom(w, r)
+	if !ok {
+		return
+	}
+
+	p.Type = parameter.Type
+	p.Required = parameter.Required
+	p.Properties = parameter.Properties
+
+	if registry.saveParameter(w, r, p) {
+		message := "Successfully replaced parameter %s for parameter group %v"
+		message = util.Message(message, p.Name, p.Id)
+		api.SuccessfulSystemPut(w, r, message)
+	}
+}
+
+func PatchSystemParameter(w http.ResponseWriter, r *http.Request) {
+	var parameter api.PatchSystemParameterRequest
+	json.NewDecoder(r.Body).Decode(&parameter)
+
+	registry, done := edit()
+	defer done()
+
+	p, ok := registry.parameterFrom(w, r)
+	if !ok {
+		return
+	}
+
+	if parameter.Type != nil {
+		p.Type = *parameter.Type
+	}
+	if parameter.Required != nil {
+		p.Required = *parameter.Required
+	}
+	if parameter.Properties != nil {
+		p.Properties = *parameter.Properties
+	}
+
+	if registry.saveParameter(w, r, p) {
+		message := "Successfully updated parameter %s for parameter group %v"
+		message = util.Message(message, p.Name, p.Id)
+		api.SuccessfulSystemPatch(w, r, message)
+	}
+}
+
+func DeleteSystemParameter(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	p, ok := registry.parameterFrom(w, r)
+	if !ok {
+		return
+	}
+
+	// a group only exists through its parameters, so
+	// the last one goes only once nothing is attached to it
+	last := len(registry.parameters[p.Id]) == 1
+	if last && registry.parametersReferenced(p.Id) {
+		message := "%s is the last parameter of group %v, which is still attached to an endpoint or method"
+		message = util.Message(message, p.Name, p.Id)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.remove("parameter", p.Id, p.Name)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	delete(registry.parameters[p.Id], p.Name)
+	if last {
+		delete(registry.parameters, p.Id)
+	}
+	publish(registry)
+
+	message := "Successfully removed parameter %s from parameter group %v"
+	message = util.Message(message, p.Name, p.Id)
+	api.SuccessfulSystemDelete(w, r, message)
+}
+
+func DeleteSystemParameterGroup(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	id, _, ok := registry.parameterGroupFrom(w, r)
+	if !ok {
+		return
+	}
+
+	if registry.parametersReferenced(id) {
+		message := "parameter group %v is still attached to an endpoint or method"
+		message = util.Message(message, id)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.remove("parameter", id, "")
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	delete(registry.parameters, id)
+	publish(registry)
+
+	message := "Successfully removed parameter group %v"
+	message = util.Message(message, id)
+	api.SuccessfulSystemDelete(w, r, message)
+}
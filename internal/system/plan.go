@@ -0,0 +1,265 @@
+package system
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+)
+
+// shape describes a parameter group by content rather than by name,
+// object schemas being expanded until they refer back to themselves
+func (d document) shape(group string, seen ...string) any {
+	if group == "" {
+		return nil
+	}
+	if slices.Contains(seen, group) {
+		return "recursive " + strconv.Itoa(len(seen)-slices.Index(seen, group))
+	}
+
+	var shape = make(map[string]any)
+	for name, p := range d.Parameters[group] {
+		props := make(map[string]any)
+		for key, value := range d.Properties[p.Properties] {
+			props[key] = value
+			if key == "schema" {
+				props[key] = d.shape(value, append(seen, group)...)
+			}
+		}
+		shape[name] = []any{p.Type, p.Required, props}
+	}
+	return shape
+}
+
+// differs reports whether two descriptions encode differently
+func differs(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) != string(y)
+}
+
+// reachable lists the parameter groups the endpoints make use of
+func (d document) reachable() map[string]bool {
+	var reached = make(map[string]bool)
+	var visit func(group string)
+	visit = func(group string) {
+		if group == "" || reached[group] {
+			return
+		}
+		reached[group] = true
+		for _, p := range d.Parameters[group] {
+			visit(d.Properties[p.Properties]["schema"])
+		}
+	}
+
+	for _, e := range d.Endpoints {
+		visit(e.UriParams)
+		for _, m := range e.Methods {
+			visit(m.Query)
+			visit(m.Headers)
+			visit(m.Body)
+		}
+	}
+
+	return reached
+}
+
+// standalone lists the shapes of the groups no endpoint reaches
+func (d document) standalone() (map[string]string, map[string]string) {
+	var reached = d.reachable()
+
+	var used = make(map[string]bool)
+	for _, params := range d.Parameters {
+		for _, p := range params {
+			used[p.Properties] = true
+		}
+	}
+
+	var parameters = make(map[string]string)
+	for name := range d.Parameters {
+		if !reached[name] {
+			shape, _ := json.Marshal(d.shape(name))
+			parameters[name] = string(shape)
+		}
+	}
+
+	var properties = make(map[string]string)
+	for name, props := range d.Properties {
+		if !used[name] {
+			shape, _ := json.Marshal(props)
+			properties[name] = string(shape)
+		}
+	}
+
+	return parameters, properties
+}
+
+// plan lists the changes that bring the current catalog to the desired one
+func plan(current, desired document) []string {
+	var changes []string
+
+	for _, path := range slices.Sorted(maps.Keys(current.Endpoints)) {
+		if _, ok := desired.Endpoints[path]; !ok {
+			changes = append(changes, "- endpoint "+path)
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(desired.Endpoints)) {
+		want := desired.Endpoints[path]
+		have, ok := current.Endpoints[path]
+		if !ok {
+			changes = append(changes, "+ endpoint "+path)
+			continue
+		}
+
+		if differs(current.shape(have.UriParams), desired.shape(want.UriParams)) {
+			changes = append(changes, "~ endpoint "+path+" uri parameters")
+		}
+		if have.Table != want.Table {
+			changes = append(changes, "~ endpoint "+path+" table")
+		}
+		if differs(have.Upstream, want.Upstream) {
+			changes = append(changes, "~ endpoint "+path+" upstream")
+		}
+
+		for _, verb := range slices.Sorted(maps.Keys(have.Methods)) {
+			if _, ok := want.Methods[verb]; !ok {
+				changes = append(changes, "- method "+verb+" "+path)
+			}
+		}
+
+		for _, verb := range slices.Sorted(maps.Keys(want.Methods)) {
+			before, ok := have.Methods[verb]
+			if !ok {
+				changes = append(changes, "+ method "+verb+" "+path)
+				continue
+			}
+
+			after := want.Methods[verb]
+			for _, section := range [][3]string{
+				{"query", before.Query, after.Query},
+				{"headers", before.Headers, after.Headers},
+				{"body", before.Body, after.Body},
+			} {
+				if differs(current.shape(section[1]), desired.shape(section[2])) {
+					changes = append(changes, "~ method "+verb+" "+path+" "+section[0])
+				}
+			}
+			if differs(before.Examples, after.Examples) {
+				changes = append(changes, "~ method "+verb+" "+path+" examples")
+			}
+		}
+	}
+
+	// groups outside of any endpoint are matched by content alone
+	var compare = func(kind string, have, want map[string]string) {
+		var counts = make(map[string]int)
+		for _, shape := range have {
+			counts[shape]++
+		}
+		for _, name := range slices.Sorted(maps.Keys(want)) {
+			if counts[want[name]] == 0 {
+				changes = append(changes, "+ "+kind+" group "+name)
+			}
+			counts[want[name]]--
+		}
+		for _, name := range slices.Sorted(maps.Keys(have)) {
+			if counts[have[name]] > 0 {
+				changes = append(changes, "- "+kind+" group "+name)
+				counts[have[name]]--
+			}
+		}
+	}
+
+	haveParameters, haveProperties := current.standalone()
+	wantParameters, wantProperties := desired.standalone()
+	compare("parameter", haveParameters, wantParameters)
+	compare("property", haveProperties, wantProperties)
+
+	return changes
+}
+
+// catalogFrom reads the desired catalog from the request body
+func catalogFrom(w http.ResponseWriter, r *http.Request) (document, bool) {
+	var desired document
+
+	if !parseRequest(w, r, "catalog", &desired) {
+		return desired, false
+	}
+
+	if problems := desired.check(r.Context()); len(problems) != 0 {
+		message := "catalog is not valid: %s"
+		message = util.Message(message, strings.Join(problems, ", "))
+		api.RequestErrorHandler(w, r, message)
+		return desired, false
+	}
+
+	return desired, true
+}
+
+func PostSystemCatalogPlan(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
+
+	desired, ok := catalogFrom(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.PostSystemCatalogReport{
+		Changes: plan(registry.snapshot(), desired),
+	})
+}
+
+// PostSystemCatalogApply converges the catalog onto the desired one by
+// rewriting it within a single transaction, endpoints keeping their ids
+func PostSystemCatalogApply(w http.ResponseWriter, r *http.Request) {
+	desired, ok := catalogFrom(w, r)
+	if !ok {
+		return
+	}
+
+	registry, done := edit()
+	defer done()
+
+	var report = api.PostSystemCatalogReport{Changes: plan(registry.snapshot(), desired)}
+	if len(report.Changes) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	var ids = make(map[string]int)
+	for id, e := range registry.endpoints {
+		ids[e.Path] = id
+	}
+
+	var written rows
+	err := store.transaction(r.Context(), func(tx writer) (err error) {
+		for _, table := range tables {
+			if err = tx.clear(table); err != nil {
+				return err
+			}
+		}
+
+		written, err = install(tx, desired, ids)
+		return err
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry = blank()
+	registry.merge(written)
+	publish(registry)
+
+	report.Applied = true
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,160 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/internal/util"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// postgres keeps the catalog in the tables of the database
+type postgres struct{}
+
+func (p postgres) load() (_registry, error) {
+	return p.read(tables...)
+}
+
+// read loads the rows of the given tables only, all of them
+// from the same snapshot so that their references agree
+func (postgres) read(names ...string) (_registry, error) {
+	var db = util.Database
+	var loaded = blank()
+
+	err := db.Snapshot(db.Ctx, func(tx util.Tx) error {
+		for _, table := range names {
+			stmt := "SELECT * FROM " + table
+			rows, err := tx.Query(stmt)
+			if err != nil {
+				message := "failed to fetch %ss from system: %s"
+				return errors.New(util.Message(message, table, err.Error()))
+			}
+
+			row := rowOf(table)
+			err = db.ForEach(rows, row, func() error {
+				loaded.add(deref(row))
+				return nil
+			})
+			if err != nil {
+				message := "failed to read %ss from system: %s"
+				return errors.New(util.Message(message, table, err.Error()))
+			}
+		}
+		return nil
+	})
+
+	return loaded, err
+}
+
+func (postgres) bindable(ctx context.Context, table string) (bool, error) {
+	var db = util.Database
+	var bindable bool
+
+	// tables and views of user schemas, the catalog tables excepted
+	sql := `SELECT EXISTS (
+				SELECT 1 FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE c.oid = to_regclass($1)
+				AND c.relkind IN ('r', 'p', 'v')
+				AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+				AND n.nspname NOT LIKE 'pg\_%'
+				AND c.oid NOT IN (
+					SELECT to_regclass(t)::oid FROM unnest($2::text[]) AS t
+					WHERE to_regclass(t) IS NOT NULL
+				)
+			)`
+	// the name is resolved quoted, as the rest handlers query it, so that
+	// it is not folded to the lower case name of another table
+	quoted := pgx.Identifier(strings.Split(table, ".")).Sanitize()
+	err := db.QueryRow(ctx, &bindable, sql, quoted, append(slices.Clone(tables), "schema_migrations"))
+	return bindable, err
+}
+
+func (postgres) transaction(ctx context.Context, method func(tx writer) error) error {
+	var db = util.Database
+
+	return db.Transaction(ctx, func(tx util.Tx) error {
+		var s = statements{tx, make(map[string]bool)}
+		if err := method(s); err != nil {
+			return err
+		}
+		return notify(tx, s.changed)
+	})
+}
+
+// statements writes rows through the statements of a transaction,
+// keeping track of the tables it changes
+type statements struct {
+	tx      util.Tx
+	changed map[string]bool
+}
+
+func (s statements) allocate(table string) (int, error) {
+	var id int
+	sql := `SELECT nextval(pg_get_serial_sequence($1, 'id'))`
+	err := s.tx.QueryRow(&id, sql, table)
+	return id, err
+}
+
+func (s statements) insert(row any) error {
+	var id = identify(row)
+	var names, values = columns(row)
+	var quoted, placeholders []string
+	var args []any
+
+	for i, name := range names {
+		if name == "id" && *id == 0 {
+			continue
+		}
+		quoted = append(quoted, pgx.Identifier{name}.Sanitize())
+		args = append(args, values[i])
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	s.changed[tableOf(row)] = true
+	sql := "INSERT INTO " + tableOf(row) + " (" + strings.Join(quoted, ", ") +
+		") VALUES (" + strings.Join(placeholders, ", ") + ")"
+	if *id != 0 {
+		return s.tx.Exec(sql, args...)
+	}
+	return s.tx.QueryRow(id, sql+" RETURNING id", args...)
+}
+
+func (s statements) update(row any) error {
+	var table = tableOf(row)
+	var names, values = columns(row)
+	var assignments, keys []string
+	var args []any
+
+	for i, name := range names {
+		args = append(args, values[i])
+		column := pgx.Identifier{name}.Sanitize() + " = $" + strconv.Itoa(len(args))
+
+		if name == "id" || (name == "name" && grouped(table)) {
+			keys = append(keys, column)
+		} else {
+			assignments = append(assignments, column)
+		}
+	}
+
+	s.changed[table] = true
+	sql := "UPDATE " + table + " SET " + strings.Join(assignments, ", ") +
+		" WHERE " + strings.Join(keys, " AND ")
+	return s.tx.Exec(sql, args...)
+}
+
+func (s statements) remove(table string, id int, name string) error {
+	s.changed[table] = true
+	if name == "" {
+		return s.tx.Exec("DELETE FROM "+table+" WHERE id = $1", id)
+	}
+	return s.tx.Exec("DELETE FROM "+table+" WHERE id = $1 AND name = $2", id, name)
+}
+
+func (s statements) clear(table string) error {
+	s.changed[table] = true
+	return s.tx.Exec("DELETE FROM " + table)
+}
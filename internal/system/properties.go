@@ -0,0 +1,306 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/util"
+
+	"github.com/go-chi/chi"
+)
+
+func (registry _registry) propertyGroupFrom(w http.ResponseWriter, r *http.Request) (int, map[string]string, bool) {
+	property := chi.URLParam(r, "property")
+
+	id, err := isId(property)
+	if err != nil {
+		api.RequestErrorHandler(w, r, err.Error())
+		return 0, nil, false
+	}
+
+	properties, ok := registry.properties[id]
+	if !ok {
+		message := "no property group possesses id %s"
+		message = util.Message(message, property)
+		api.NotFoundErrorHandler(w, r, message)
+	}
+
+	return id, properties, ok
+}
+
+// propertyRules reports the first property which validate would not honour
+// for the type, along with unknown items, malformed bounds, lengths and
+// patterns, and the items or schema arrays and objects cannot do without
+func propertyRules(typ string, props map[string]string) error {
+	if unknown := unknownProperties(typ, props); len(unknown) != 0 {
+		message := "%s not allowed for %s parameters"
+		return errors.New(util.Message(message, strings.Join(unknown, ", "), typ))
+	}
+
+	if _, ok := props["items"]; !ok && typ == "array" {
+		return errors.New("array parameters require the items property")
+	}
+	if _, ok := props["schema"]; !ok && typ == "object" {
+		return errors.New("object parameters require the schema property")
+	}
+
+	if items, ok := props["items"]; ok && !slices.Contains(types, items) {
+		message := "%s (%s) must be in (%s)"
+		return errors.New(util.Message(message, "items", items, strings.Join(types, ",")))
+	} else if items == "array" {
+		return errors.New("items (array) must not be nested arrays")
+	}
+
+	for _, name := range bounds {
+		value, ok := props[name]
+		if _, number := finite(value); ok && !number {
+			message := "%s (%s) must be a finite number"
+			return errors.New(util.Message(message, name, value))
+		}
+	}
+
+	for _, name := range lengths {
+		value, ok := props[name]
+		if length, err := strconv.Atoi(value); ok && (err != nil || length < 0) {
+			message := "%s (%s) must be a non-negative integer"
+			return errors.New(util.Message(message, name, value))
+		}
+	}
+
+	if pattern, ok := props["pattern"]; ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			message := "pattern (%s) must be a valid regular expression"
+			return errors.New(util.Message(message, pattern))
+		}
+	}
+
+	return nil
+}
+
+// checkProperties applies propertyRules, the schema
+// having to name an existing parameter group as well
+func (registry _registry) checkProperties(w http.ResponseWriter, r *http.Request, typ string, props map[string]string) bool {
+	if err := propertyRules(typ, props); err != nil {
+		api.RequestErrorHandler(w, r, err.Error())
+		return false
+	}
+
+	if schema, ok := props["schema"]; ok {
+		if group, err := strconv.Atoi(schema); err != nil || registry.parameters[group] == nil {
+			message := "schema (%s) must be an existing parameter group"
+			message = util.Message(message, schema)
+			api.RequestErrorHandler(w, r, message)
+			return false
+		}
+	}
+
+	return true
+}
+
+// propertiesReferenced reports whether any parameter is linked to the property group
+func (registry _registry) propertiesReferenced(group int) bool {
+	for _, params := range registry.parameters {
+		for _, p := range params {
+			if p.Properties == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkReferences applies checkProperties for the type
+// of every parameter linked to the property group
+func (registry _registry) checkReferences(w http.ResponseWriter, r *http.Request, group int, props map[string]string) bool {
+	for _, params := range registry.parameters {
+		for _, p := range params {
+			if p.Properties == group && !registry.checkProperties(w, r, p.Type, props) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func PostSystemPropertyGroup(w http.ResponseWriter, r *http.Request) {
+	var property api.PostSystemPropertyRequest
+	json.NewDecoder(r.Body).Decode(&property)
+
+	registry, done := edit()
+	defer done()
+
+	if len(property.Properties) == 0 {
+		message := "at least one property must be provided"
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	if !slices.Contains(types, property.Type) {
+		message := "%s (%s) must be in (%s)"
+		message = util.Message(message, "type", property.Type, strings.Join(types, ","))
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	if !registry.checkProperties(w, r, property.Type, property.Properties) {
+		return
+	}
+
+	var id int
+	names := slices.Sorted(maps.Keys(property.Properties))
+	err := store.transaction(r.Context(), func(tx writer) error {
+		for _, name := range names {
+			row := _property{id, name, property.Properties[name]}
+			if err := tx.insert(&row); err != nil {
+				return err
+			}
+			id = row.Id
+		}
+		return nil
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry.properties[id] = maps.Clone(property.Properties)
+	publish(registry)
+
+	message := "Successfully registered property group %v with %s"
+	message = util.Message(message, id, strings.Join(names, ", "))
+	api.SuccessfulSystemPost(w, r, message)
+}
+
+func PutSystemProperty(w http.ResponseWriter, r *http.Request) {
+	var value string
+	json.NewDecoder(r.Body).Decode(&value)
+
+	registry, done := edit()
+	defer done()
+
+	id, properties, ok := registry.propertyGroupFrom(w, r)
+	if !ok {
+		return
+	}
+
+	var name = chi.URLParam(r, "name")
+
+	props := maps.Clone(properties)
+	props[name] = value
+	if !registry.checkReferences(w, r, id, props) {
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		row := _property{id, name, value}
+		if _, ok := properties[name]; ok {
+			return tx.update(&row)
+		}
+		return tx.insert(&row)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	registry.properties[id][name] = value
+	publish(registry)
+
+	message := "Successfully set %s to %s for property group %v"
+	message = util.Message(message, name, value, id)
+	api.SuccessfulSystemPut(w, r, message)
+}
+
+func DeleteSystemProperty(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	id, properties, ok := registry.propertyGroupFrom(w, r)
+	if !ok {
+		return
+	}
+
+	var name = chi.URLParam(r, "name")
+	if _, ok = properties[name]; !ok {
+		message := "%s is not set for property group %v"
+		message = util.Message(message, name, id)
+		api.NotFoundErrorHandler(w, r, message)
+		return
+	}
+
+	// the parameters linked to the group must do without the property
+	props := maps.Clone(properties)
+	delete(props, name)
+	if !registry.checkReferences(w, r, id, props) {
+		return
+	}
+
+	// and a group only exists through its properties
+	if len(props) == 0 && registry.propertiesReferenced(id) {
+		message := "%s is the last property of group %v, which is still referenced by a parameter"
+		message = util.Message(message, name, id)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.remove("property", id, name)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	delete(registry.properties[id], name)
+	if len(registry.properties[id]) == 0 {
+		delete(registry.properties, id)
+	}
+	publish(registry)
+
+	message := "Successfully unset %s for property group %v"
+	message = util.Message(message, name, id)
+	api.SuccessfulSystemDelete(w, r, message)
+}
+
+func DeleteSystemPropertyGroup(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	id, _, ok := registry.propertyGroupFrom(w, r)
+	if !ok {
+		return
+	}
+
+	for _, params := range registry.parameters {
+		for _, p := range params {
+			if p.Properties == id {
+				message := "property group %v is still referenced by parameter %s of group %v"
+				message = util.Message(message, id, p.Name, p.Id)
+				api.RequestErrorHandler(w, r, message)
+				return
+			}
+		}
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		return tx.remove("property", id, "")
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	delete(registry.properties, id)
+	publish(registry)
+
+	message := "Successfully removed property group %v"
+	message = util.Message(message, id)
+	api.SuccessfulSystemDelete(w, r, message)
+}
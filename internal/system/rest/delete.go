@@ -1,7 +1,28 @@
 package rest
 
-import "net/http"
+import (
+	"net/http"
+
+	"Factory/internal/system/values"
+	"Factory/internal/util"
+)
 
 func Delete(w http.ResponseWriter, r *http.Request) {
+	var db = util.Database
+	var entries = values.From(r)
+
+	table, ok := bound(w, r)
+	if !ok {
+		return
+	}
+
+	names, args := columns(entries, "uri", "query")
+	if unconditional(w, r, names) {
+		return
+	}
+
+	sql := "DELETE FROM " + table + where(names, 0) + " RETURNING *"
+	rows, err := db.Query(r.Context(), sql, args...)
 
+	respond(w, r, rows, err, len(entries["uri"]) != 0, http.StatusOK)
 }
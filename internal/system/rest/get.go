@@ -1,10 +1,24 @@
 package rest
 
 import (
-	"encoding/json"
 	"net/http"
+
+	"Factory/internal/system/values"
+	"Factory/internal/util"
 )
 
 func Get(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode("get request")
+	var db = util.Database
+	var entries = values.From(r)
+
+	table, ok := bound(w, r)
+	if !ok {
+		return
+	}
+
+	names, args := columns(entries, "uri", "query")
+	sql := "SELECT * FROM " + table + where(names, 0)
+	rows, err := db.Query(r.Context(), sql, args...)
+
+	respond(w, r, rows, err, len(entries["uri"]) != 0, http.StatusOK)
 }
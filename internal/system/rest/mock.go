@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"bytes"
+	"maps"
+	"net/http"
+	"slices"
+	"text/template"
+
+	"Factory/api"
+	"Factory/internal/system/values"
+	"Factory/internal/util"
+)
+
+// Example is a canned response whose body is a template
+// executed over the validated entries of the request
+type Example struct {
+	Status  int
+	Headers map[string]string
+	Body    *template.Template
+}
+
+// Mock serves the example named by the X-Mock-Example header, falling back
+// to the example named default and then to the first example by name
+func Mock(examples map[string]Example) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get("X-Mock-Example")
+		if _, ok := examples["default"]; name == "" && ok {
+			name = "default"
+		} else if name == "" && len(examples) != 0 {
+			name = slices.Min(slices.Collect(maps.Keys(examples)))
+		}
+
+		example, ok := examples[name]
+		if !ok {
+			message := "no example named %s for %s %s"
+			message = util.Message(message, name, r.Method, r.URL.Path)
+			api.NotFoundErrorHandler(w, r, message)
+			return
+		}
+
+		var body bytes.Buffer
+		if err := example.Body.Execute(&body, values.From(r)); err != nil {
+			util.GetLogger(r).Error(err)
+			api.InternalErrorHandler(w, r)
+			return
+		}
+
+		for header, value := range example.Headers {
+			w.Header().Set(header, value)
+		}
+
+		if example.Status == 0 {
+			example.Status = http.StatusOK
+		}
+
+		w.WriteHeader(example.Status)
+		w.Write(body.Bytes())
+	}
+}
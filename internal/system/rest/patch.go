@@ -3,5 +3,5 @@ package rest
 import "net/http"
 
 func Patch(w http.ResponseWriter, r *http.Request) {
-
+	update(w, r)
 }
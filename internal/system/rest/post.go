@@ -1,7 +1,35 @@
 package rest
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Factory/internal/system/values"
+	"Factory/internal/util"
+)
 
 func Post(w http.ResponseWriter, r *http.Request) {
+	var db = util.Database
+	var entries = values.From(r)
+
+	table, ok := bound(w, r)
+	if !ok {
+		return
+	}
+
+	names, args := columns(entries, "uri", "body")
+	sql := "INSERT INTO " + table + " DEFAULT VALUES RETURNING *"
+	if len(names) != 0 {
+		var placeholders []string
+		for i := range names {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i+1))
+		}
+
+		sql = "INSERT INTO " + table + " (" + strings.Join(names, ", ") + ")" +
+			" VALUES (" + strings.Join(placeholders, ", ") + ") RETURNING *"
+	}
+	rows, err := db.Query(r.Context(), sql, args...)
 
+	respond(w, r, rows, err, true, http.StatusCreated)
 }
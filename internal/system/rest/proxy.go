@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"Factory/api"
+	"Factory/internal/util"
+
+	"github.com/go-chi/chi"
+)
+
+// Upstream describes the service requests are forwarded to once validated.
+// Every {name} of the target is replaced by the matching uri parameter,
+// only the passthrough headers being forwarded unless none are listed
+type Upstream struct {
+	Target      string
+	Timeout     time.Duration
+	Passthrough []string
+	Inject      map[string]string
+}
+
+// braces strips the delimiters of the {name} segments of a target
+var braces = strings.NewReplacer("{", "", "}", "")
+
+func (u Upstream) resolve(r *http.Request) (*url.URL, error) {
+	var target = u.Target
+	for _, segment := range strings.Split(u.Target, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			value := chi.URLParam(r, braces.Replace(segment))
+
+			// chi routes on the escaped path when the request has one,
+			// its parameters then having to be unescaped before escaping
+			if r.URL.RawPath != "" {
+				if unescaped, err := url.PathUnescape(value); err == nil {
+					value = unescaped
+				}
+			}
+			target = strings.Replace(target, segment, url.PathEscape(value), 1)
+		}
+	}
+
+	resolved, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.URL.RawQuery != "" {
+		if resolved.RawQuery != "" {
+			resolved.RawQuery += "&"
+		}
+		resolved.RawQuery += r.URL.RawQuery
+	}
+
+	return resolved, nil
+}
+
+// Proxy forwards requests to the upstream, propagating the correlation id
+func Proxy(upstream Upstream) http.Handler {
+	var passthrough []string
+	for _, header := range upstream.Passthrough {
+		passthrough = append(passthrough, http.CanonicalHeaderKey(header))
+	}
+
+	if upstream.Timeout == 0 {
+		upstream.Timeout = 30 * time.Second
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			target, _ := upstream.resolve(pr.In)
+			pr.Out.URL = target
+			pr.Out.Host = target.Host
+			pr.SetXForwarded()
+
+			if len(passthrough) != 0 {
+				for header := range pr.Out.Header {
+					if !slices.Contains(passthrough, header) && !strings.HasPrefix(header, "X-Forwarded-") {
+						pr.Out.Header.Del(header)
+					}
+				}
+			}
+
+			for header, value := range upstream.Inject {
+				pr.Out.Header.Set(header, value)
+			}
+			pr.Out.Header.Set("X-Correlation-ID", pr.In.Header.Get("X-Correlation-ID"))
+		},
+		ModifyResponse: func(response *http.Response) error {
+			// the correlation id is already set by the middleware
+			response.Header.Del("X-Correlation-ID")
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			util.GetLogger(r).Error(err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				message := "upstream %s did not respond in time"
+				message = util.Message(message, upstream.Target)
+				api.GatewayTimeoutErrorHandler(w, r, message)
+			} else {
+				message := "upstream %s is unavailable"
+				message = util.Message(message, upstream.Target)
+				api.GatewayErrorHandler(w, r, message)
+			}
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := upstream.resolve(r); err != nil {
+			util.GetLogger(r).Error(err)
+			api.InternalErrorHandler(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), upstream.Timeout)
+		defer cancel()
+
+		r = r.WithContext(ctx)
+		r.Header = r.Header.Clone()
+		r.Header.Set("X-Correlation-ID", w.Header().Get("X-Correlation-ID"))
+
+		proxy.ServeHTTP(w, r)
+	})
+}
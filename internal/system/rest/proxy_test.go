@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Factory/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+// forward serves path through a proxy to upstream, as the catalog does
+func forward(path string, upstream Upstream) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Correlation)
+	r.Handle(path, Proxy(upstream))
+	return r
+}
+
+func TestProxy(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.Header().Set("X-Correlation-ID", "upstream")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	h := forward("/users/{id}", Upstream{
+		Target:      server.URL + "/u/{id}/profile?source=factory",
+		Passthrough: []string{"accept"},
+		Inject:      map[string]string{"Authorization": "Bearer token"},
+	})
+
+	var cases = []struct {
+		path     string
+		wantPath string
+	}{
+		{"/users/42?fields=name", "/u/42/profile"},
+		{"/users/a%2Fb", "/u/a%2Fb/profile"},
+		{"/users/a%20b", "/u/a%20b/profile"},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Header.Set("Accept", "application/json")
+		r.Header.Set("Cookie", "session=secret")
+		r.Header.Set("X-Correlation-ID", "correlated")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusTeapot {
+			t.Fatalf("%s: expected the upstream status, got %v: %s", c.path, w.Code, w.Body.String())
+		}
+		if got := received.URL.EscapedPath(); got != c.wantPath {
+			t.Errorf("%s: expected %s upstream, got %s", c.path, c.wantPath, got)
+		}
+		if got := w.Header().Values("X-Correlation-ID"); len(got) != 1 || got[0] != "correlated" {
+			t.Errorf("%s: expected a single correlation id in the response, got %v", c.path, got)
+		}
+	}
+
+	if got := received.URL.Query(); got.Get("source") != "factory" {
+		t.Errorf("expected the query of the target to be kept, got %v", got)
+	}
+
+	r := httptest.NewRequest("GET", "/users/42?fields=name", nil)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Cookie", "session=secret")
+	r.Header.Set("X-Correlation-ID", "correlated")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	for header, want := range map[string]string{
+		"Accept":           "application/json",
+		"Authorization":    "Bearer token",
+		"Cookie":           "",
+		"X-Correlation-Id": "correlated",
+	} {
+		if got := received.Header.Get(header); got != want {
+			t.Errorf("expected %s to be %q upstream, got %q", header, want, got)
+		}
+	}
+	if got := received.URL.Query().Get("fields"); got != "name" {
+		t.Errorf("expected the query to be passed through, got %q", got)
+	}
+}
+
+func TestProxyTimeout(t *testing.T) {
+	var release = make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	h := forward("/slow", Upstream{Target: server.URL, Timeout: 50 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %v: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProxyUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	h := forward("/gone", Upstream{Target: server.URL})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/gone", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %v: %s", w.Code, w.Body.String())
+	}
+}
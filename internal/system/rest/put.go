@@ -1,6 +1,50 @@
 package rest
 
-import "net/http"
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/system/values"
+	"Factory/internal/util"
+)
+
+// update sets the body columns of the rows identified by the uri parameters
+func update(w http.ResponseWriter, r *http.Request) {
+	var db = util.Database
+	var entries = values.From(r)
+
+	table, ok := bound(w, r)
+	if !ok {
+		return
+	}
+
+	names, args := columns(entries, "body")
+	if len(names) == 0 {
+		message := "no columns provided to update at %s"
+		message = util.Message(message, r.URL.Path)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	var assignments []string
+	for i, name := range names {
+		assignments = append(assignments, name+" = $"+strconv.Itoa(i+1))
+	}
+
+	keys, keyArgs := columns(entries, "uri")
+	if unconditional(w, r, keys) {
+		return
+	}
+
+	sql := "UPDATE " + table + " SET " + strings.Join(assignments, ", ") +
+		where(keys, len(args)) + " RETURNING *"
+	rows, err := db.Query(r.Context(), sql, append(args, keyArgs...)...)
+
+	respond(w, r, rows, err, true, http.StatusOK)
+}
 
 func Put(w http.ResponseWriter, r *http.Request) {
+	update(w, r)
 }
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"Factory/api"
+	"Factory/internal/system/values"
+	"Factory/internal/util"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Bind makes the table the source of the rows served by the rest handlers
+func Bind(table string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), "table", table)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bound returns the quoted name of the table bound to the request
+func bound(w http.ResponseWriter, r *http.Request) (string, bool) {
+	table, ok := r.Context().Value("table").(string)
+	if !ok {
+		message := "no table is bound to %s"
+		message = util.Message(message, r.URL.Path)
+		api.NotImplementedErrorHandler(w, r, message)
+		return "", false
+	}
+
+	if util.Database.Conn == nil {
+		message := "tables require the postgres storage"
+		api.NotImplementedErrorHandler(w, r, message)
+		return "", false
+	}
+
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize(), true
+}
+
+// columns lists the entries of the sections as
+// quoted column names and their matching arguments
+func columns(entries values.Entries, sections ...string) ([]string, []any) {
+	var names []string
+	var args []any
+
+	for _, section := range sections {
+		for _, name := range slices.Sorted(maps.Keys(entries[section])) {
+			names = append(names, pgx.Identifier{name}.Sanitize())
+			args = append(args, entries[section][name])
+		}
+	}
+
+	return names, args
+}
+
+// where builds a conjunction of equality conditions whose
+// placeholders start after the given number of arguments
+func where(names []string, offset int) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var conditions []string
+	for i, name := range names {
+		conditions = append(conditions, name+" = $"+strconv.Itoa(offset+i+1))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// unconditional rejects statements that would affect every row of the table
+func unconditional(w http.ResponseWriter, r *http.Request, names []string) bool {
+	if len(names) == 0 {
+		message := "refusing to %s every row bound to %s"
+		message = util.Message(message, strings.ToLower(r.Method), r.URL.Path)
+		api.RequestErrorHandler(w, r, message)
+		return true
+	}
+	return false
+}
+
+// respond encodes the returned rows, a single row being expected
+// when the request identifies one through its uri parameters
+func respond(w http.ResponseWriter, r *http.Request, rows pgx.Rows, err error, single bool, code int) {
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	results, err := pgx.CollectRows(rows, pgx.RowToMap)
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	if !single {
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	if len(results) == 0 {
+		message := "no row found at %s"
+		message = util.Message(message, r.URL.Path)
+		api.NotFoundErrorHandler(w, r, message)
+		return
+	}
+
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(results[0])
+}
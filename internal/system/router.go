@@ -0,0 +1,82 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// router serves the catalog through a route tree which is rebuilt
+// from each published registry and swapped in atomically whenever it changes.
+// Requests already dispatched to a previous tree finish on that tree.
+type router struct {
+	mutex sync.Mutex
+	tree  atomic.Pointer[chi.Mux]
+}
+
+var routes router
+
+// rebuild swaps in the route tree of a registry, the previous tree being kept,
+// or none served at all, when the paths of the registry cannot be routed
+func (rt *router) rebuild(registry _registry) {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	tree, err := build(registry)
+	if err != nil {
+		logrus.WithError(err).Error("failed to rebuild the routes of the catalog")
+		if rt.tree.Load() != nil {
+			return
+		}
+		tree = chi.NewRouter()
+	}
+	rt.tree.Store(tree)
+}
+
+// build builds the route tree of a registry, recovering from the panics
+// chi raises on malformed or conflicting route patterns
+func build(registry _registry) (tree *chi.Mux, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tree, err = nil, fmt.Errorf("%v", recovered)
+		}
+	}()
+
+	tree = chi.NewRouter()
+	catalog(tree, registry)
+	return tree, nil
+}
+
+// checkRoutes reports why the given paths cannot be routed side by side
+func checkRoutes(paths ...string) error {
+	var registry = blank()
+	for i, path := range paths {
+		registry.endpoints[i+1] = _endpoint{Id: i + 1, Path: path}
+	}
+
+	_, err := build(registry)
+	return err
+}
+
+// paths lists the paths of the registered endpoints but the one of id
+func (registry _registry) paths(id int) []string {
+	var paths []string
+	for _, e := range registry.endpoints {
+		if e.Id != id {
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// detach the routing context of the parent router
+	// so the catalog tree resolves the full request path
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+	rt.tree.Load().ServeHTTP(w, r.WithContext(ctx))
+}
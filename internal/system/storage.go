@@ -0,0 +1,102 @@
+package system
+
+import (
+	"context"
+	"reflect"
+)
+
+// storage persists the catalog tables the registry is loaded from
+type storage interface {
+	load() (_registry, error)
+	// bindable reports whether rest handlers may be bound to a table,
+	// which must exist outside of the catalog and the system schemas
+	bindable(ctx context.Context, table string) (bool, error)
+	transaction(ctx context.Context, method func(tx writer) error) error
+}
+
+// writer changes the catalog tables within a transaction; rows are pointers
+// to _endpoint, _method, _parameter, _property, _example or _upstream values
+type writer interface {
+	// allocate reserves a new id in a table, for groups created up front
+	allocate(table string) (int, error)
+	// insert adds a row, giving it a new id when its id is zero
+	insert(row any) error
+	// update replaces the row with the same id, and name within groups
+	update(row any) error
+	// remove deletes a named row of a group, or the whole group without a name
+	remove(table string, id int, name string) error
+	// clear deletes every row of a table
+	clear(table string) error
+}
+
+var store storage
+
+// tables lists the catalog tables in the order they are loaded
+var tables = []string{"endpoint", "method", "parameter", "property", "example", "upstream"}
+
+// tableOf names the table a row belongs to
+func tableOf(row any) string {
+	switch row.(type) {
+	case *_endpoint, _endpoint:
+		return "endpoint"
+	case *_method, _method:
+		return "method"
+	case *_parameter, _parameter:
+		return "parameter"
+	case *_property, _property:
+		return "property"
+	case *_example, _example:
+		return "example"
+	case *_upstream, _upstream:
+		return "upstream"
+	}
+	panic("not a catalog row")
+}
+
+// rowOf returns a new row for a table
+func rowOf(table string) any {
+	switch table {
+	case "endpoint":
+		return &_endpoint{}
+	case "method":
+		return &_method{}
+	case "parameter":
+		return &_parameter{}
+	case "property":
+		return &_property{}
+	case "example":
+		return &_example{}
+	case "upstream":
+		return &_upstream{}
+	}
+	panic("not a catalog table")
+}
+
+// grouped reports whether the rows of a table are keyed by id and name
+func grouped(table string) bool {
+	return table != "endpoint" && table != "upstream"
+}
+
+// columns lists the db tagged columns of a row alongside their values
+func columns(row any) ([]string, []any) {
+	var names []string
+	var values []any
+
+	value := reflect.ValueOf(row).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		names = append(names, value.Type().Field(i).Tag.Get("db"))
+		values = append(values, value.Field(i).Interface())
+	}
+
+	return names, values
+}
+
+// identify returns a pointer to the id of a row
+func identify(row any) *int {
+	return reflect.ValueOf(row).Elem().FieldByName("Id").Addr().Interface().(*int)
+}
+
+// deref returns the row a pointer points to
+func deref(row any) any {
+	return reflect.ValueOf(row).Elem().Interface()
+}
@@ -0,0 +1,129 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+
+	"Factory/internal/util"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// channel carries the changes made to the catalog tables
+const channel = "catalog"
+
+// instance tells the notifications of this instance apart from those of others
+var instance = uuid.NewString()
+
+// notification names the tables an instance changed in a transaction
+type notification struct {
+	Instance string   `json:"instance"`
+	Tables   []string `json:"tables"`
+}
+
+// notify tells the other instances which tables the transaction changed,
+// postgres delivering the notification only once it is committed
+func notify(tx util.Tx, changed map[string]bool) error {
+	if len(changed) == 0 {
+		return nil
+	}
+
+	payload, _ := json.Marshal(notification{instance, slices.Sorted(maps.Keys(changed))})
+	return tx.Exec(`SELECT pg_notify($1, $2)`, channel, string(payload))
+}
+
+// Synchronize keeps the registry of this instance in line with the changes
+// other instances make to the postgres storage, reloading the tables they
+// notify and, every interval unless it is zero, the whole catalog in case
+// a notification was missed; it returns once the context is done
+func Synchronize(ctx context.Context, interval time.Duration) {
+	go listen(ctx)
+
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reload(tables...)
+		}
+	}
+}
+
+// listen reloads the tables named by the notifications of other instances,
+// listening again after a second whenever the connection is lost
+func listen(ctx context.Context) {
+	for {
+		// the whole catalog is reloaded once listening, as
+		// notifications sent in the meantime have been missed
+		err := util.Database.Listen(ctx, channel, func() { reload(tables...) }, notified)
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.WithError(err).Warn("lost the catalog notifications, listening again")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func notified(payload string) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		logrus.WithError(err).Warn("ignored a malformed catalog notification")
+		return
+	}
+
+	if n.Instance == instance {
+		return
+	}
+
+	var changed []string
+	for _, table := range n.Tables {
+		if slices.Contains(tables, table) {
+			changed = append(changed, table)
+		}
+	}
+	reload(changed...)
+}
+
+// reload replaces the given tables of the registry with their stored rows,
+// read while holding off local writers so that none of their changes is lost
+func reload(names ...string) {
+	if len(names) == 0 {
+		return
+	}
+
+	registry, done := edit()
+	defer done()
+
+	loaded, err := postgres{}.read(names...)
+	if err != nil {
+		logrus.WithError(err).Error("failed to reload the catalog")
+		return
+	}
+
+	for _, table := range names {
+		registry.replace(loaded, table)
+	}
+
+	// a catalog no route tree can be built from is left unpublished
+	if _, err := build(registry); err != nil {
+		logrus.WithError(err).Error("failed to route the reloaded catalog")
+		return
+	}
+	publish(registry)
+}
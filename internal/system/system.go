@@ -12,7 +12,9 @@ func system(r *chi.Mux) {
 
 	r.Get("/system/endpoints", GetSystemEndpoints)
 	r.Get("/system/endpoints/{endpoint}", GetSystemEndpointById)
+	r.Get("/system/endpoints/{endpoint}/upstream", GetSystemUpstream)
 	r.Get("/system/endpoints/{endpoint}/{method}", GetSystemMethod)
+	r.Get("/system/endpoints/{endpoint}/{method}/examples", GetSystemExamples)
 
 	r.Get("/system/parameters", GetSystemParameters)
 	r.Get("/system/parameters/{parameter}", GetSystemParameterById)
@@ -20,10 +22,66 @@ func system(r *chi.Mux) {
 	r.Get("/system/properties", GetSystemProperties)
 	r.Get("/system/properties/{property}", GetSystemPropertyById)
 
+	r.Get("/system/openapi.json", GetSystemOpenApiJson)
+	r.Get("/system/openapi.yaml", GetSystemOpenApiYaml)
+
+	r.Get("/system/export", GetSystemExport)
+	r.Get("/system/migrations", GetSystemMigrations)
+
 	/*  **************************
 	          POST REQUESTS
 		************************** */
 
 	r.Post("/system/endpoints", PostSystemEndpoint)
 	r.Post("/system/endpoints/{endpoint}/{method}", PostSystemMethod)
+	r.Post("/system/endpoints/{endpoint}/{method}/examples/{example}", PostSystemExample)
+
+	r.Post("/system/parameters", PostSystemParameterGroup)
+	r.Post("/system/parameters/{parameter}/{name}", PostSystemParameter)
+
+	r.Post("/system/properties", PostSystemPropertyGroup)
+
+	r.Post("/system/openapi", PostSystemOpenApi)
+
+	r.Post("/system/catalog/plan", PostSystemCatalogPlan)
+	r.Post("/system/catalog/apply", PostSystemCatalogApply)
+
+	r.Post("/system/import", PostSystemImport)
+
+	/*  **************************
+	           PUT REQUESTS
+		************************** */
+
+	r.Put("/system/endpoints/{endpoint}", PutSystemEndpoint)
+	r.Put("/system/endpoints/{endpoint}/upstream", PutSystemUpstream)
+	r.Put("/system/endpoints/{endpoint}/{method}", PutSystemMethod)
+	r.Put("/system/endpoints/{endpoint}/{method}/examples/{example}", PutSystemExample)
+
+	r.Put("/system/parameters/{parameter}/{name}", PutSystemParameter)
+
+	r.Put("/system/properties/{property}/{name}", PutSystemProperty)
+
+	/*  **************************
+	          PATCH REQUESTS
+		************************** */
+
+	r.Patch("/system/endpoints/{endpoint}", PatchSystemEndpoint)
+	r.Patch("/system/endpoints/{endpoint}/{method}", PatchSystemMethod)
+
+	r.Patch("/system/parameters/{parameter}/{name}", PatchSystemParameter)
+
+	/*  **************************
+	          DELETE REQUESTS
+		************************** */
+
+	r.Delete("/system/endpoints/{endpoint}", DeleteSystemEndpoint)
+	r.Delete("/system/endpoints/{endpoint}/upstream", DeleteSystemUpstream)
+	r.Delete("/system/endpoints/{endpoint}/{method}", DeleteSystemMethod)
+	r.Delete("/system/endpoints/{endpoint}/{method}/examples/{example}", DeleteSystemExample)
+
+	r.Delete("/system/parameters/{parameter}", DeleteSystemParameterGroup)
+	r.Delete("/system/parameters/{parameter}/{name}", DeleteSystemParameter)
+
+	r.Delete("/system/properties/{property}", DeleteSystemPropertyGroup)
+	r.Delete("/system/properties/{property}/{name}", DeleteSystemProperty)
 }
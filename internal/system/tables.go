@@ -1,29 +1,49 @@
 package system
 
 type _endpoint struct {
-	id        int
-	path      string
-	uriParams int
-	methods   int
+	Id        int    `db:"id" json:"id"`
+	Path      string `db:"path" json:"path"`
+	UriParams int    `db:"uriParams" json:"uriParams"`
+	Methods   int    `db:"methods" json:"methods"`
+	Table     string `db:"table" json:"table"`
+	Upstream  int    `db:"upstream" json:"upstream"`
 }
 
 type _method struct {
-	id      int
-	name    string
-	query   int
-	headers int
+	Id       int    `db:"id" json:"id"`
+	Name     string `db:"name" json:"name"`
+	Query    int    `db:"query" json:"query"`
+	Headers  int    `db:"headers" json:"headers"`
+	Body     int    `db:"body" json:"body"`
+	Examples int    `db:"examples" json:"examples"`
 }
 
 type _parameter struct {
-	id         int
-	name       string
-	typ        string
-	required   bool
-	properties int
+	Id         int    `db:"id" json:"id"`
+	Name       string `db:"name" json:"name"`
+	Type       string `db:"type" json:"type"`
+	Required   bool   `db:"required" json:"required"`
+	Properties int    `db:"properties" json:"properties"`
 }
 
 type _property struct {
-	id    int
-	name  string
-	value string
+	Id    int    `db:"id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Value string `db:"value" json:"value"`
+}
+
+type _example struct {
+	Id      int    `db:"id" json:"id"`
+	Name    string `db:"name" json:"name"`
+	Status  int    `db:"status" json:"status"`
+	Headers string `db:"headers" json:"headers"`
+	Body    string `db:"body" json:"body"`
+}
+
+type _upstream struct {
+	Id          int    `db:"id" json:"id"`
+	Target      string `db:"target" json:"target"`
+	Timeout     int    `db:"timeout" json:"timeout"`
+	Passthrough string `db:"passthrough" json:"passthrough"`
+	Inject      string `db:"inject" json:"inject"`
 }
@@ -0,0 +1,162 @@
+package system
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"Factory/api"
+	"Factory/internal/system/rest"
+	"Factory/internal/util"
+)
+
+// proxy prepares the upstream of an endpoint for rest.Proxy
+func proxy(u _upstream) http.Handler {
+	var inject map[string]string
+	json.Unmarshal([]byte(u.Inject), &inject)
+
+	var passthrough []string
+	if u.Passthrough != "" {
+		passthrough = strings.Split(u.Passthrough, ",")
+	}
+
+	return rest.Proxy(rest.Upstream{
+		Target:      u.Target,
+		Timeout:     time.Duration(u.Timeout) * time.Millisecond,
+		Passthrough: passthrough,
+		Inject:      inject,
+	})
+}
+
+func GetSystemUpstream(w http.ResponseWriter, r *http.Request) {
+	var registry = current()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	u, ok := registry.upstreams[e.Upstream]
+	if !ok {
+		message := "no upstream configured for %s"
+		message = util.Message(message, e.Path)
+		api.NotFoundErrorHandler(w, r, message)
+		return
+	}
+
+	var display = api.GetSystemUpstream{
+		Target:  u.Target,
+		Timeout: u.Timeout,
+	}
+	if u.Passthrough != "" {
+		display.Passthrough = strings.Split(u.Passthrough, ",")
+	}
+	json.Unmarshal([]byte(u.Inject), &display.Inject)
+
+	json.NewEncoder(w).Encode(display)
+}
+
+func PutSystemUpstream(w http.ResponseWriter, r *http.Request) {
+	var upstream api.PutSystemUpstreamRequest
+	json.NewDecoder(r.Body).Decode(&upstream)
+
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	if target, err := url.Parse(upstream.Target); err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		message := "upstream target (%s) must be an http or https URL"
+		message = util.Message(message, upstream.Target)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	if upstream.Timeout < 0 {
+		message := "upstream timeout (%v) must not be negative"
+		message = util.Message(message, upstream.Timeout)
+		api.RequestErrorHandler(w, r, message)
+		return
+	}
+
+	inject, _ := json.Marshal(upstream.Inject)
+	u := _upstream{
+		Id:          e.Upstream,
+		Target:      upstream.Target,
+		Timeout:     upstream.Timeout,
+		Passthrough: strings.Join(upstream.Passthrough, ","),
+		Inject:      string(inject),
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		if u.Id != 0 {
+			return tx.update(&u)
+		}
+
+		if err := tx.insert(&u); err != nil {
+			return err
+		}
+
+		proxied := e
+		proxied.Upstream = u.Id
+		return tx.update(&proxied)
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	e.Upstream = u.Id
+	registry.endpoints[e.Id] = e
+	registry.upstreams[u.Id] = u
+	publish(registry)
+
+	message := "Successfully configured upstream %s for %s"
+	message = util.Message(message, u.Target, e.Path)
+	api.SuccessfulSystemPut(w, r, message)
+}
+
+func DeleteSystemUpstream(w http.ResponseWriter, r *http.Request) {
+	registry, done := edit()
+	defer done()
+
+	e, ok := registry.endpointFrom(w, r)
+	if !ok {
+		return
+	}
+
+	if e.Upstream == 0 {
+		message := "no upstream configured for %s"
+		message = util.Message(message, e.Path)
+		api.NotFoundErrorHandler(w, r, message)
+		return
+	}
+
+	err := store.transaction(r.Context(), func(tx writer) error {
+		direct := e
+		direct.Upstream = 0
+		if err := tx.update(&direct); err != nil {
+			return err
+		}
+
+		return tx.remove("upstream", e.Upstream, "")
+	})
+	if err != nil {
+		api.DatabaseErrorHandler(w, r, err)
+		return
+	}
+
+	delete(registry.upstreams, e.Upstream)
+	e.Upstream = 0
+	registry.endpoints[e.Id] = e
+	publish(registry)
+
+	message := "Successfully removed the upstream of %s"
+	message = util.Message(message, e.Path)
+	api.SuccessfulSystemDelete(w, r, message)
+}
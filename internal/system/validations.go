@@ -1,157 +1,574 @@
 package system
 
 import (
-	"context"
+	"bytes"
+	"encoding/json"
 	"errors"
+	"io"
+	"maps"
+	"math"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"Factory/api"
+	"Factory/internal/system/values"
 	"Factory/internal/util"
 
 	"github.com/go-chi/chi"
+	"github.com/google/uuid"
 )
 
-type entries map[string]map[string]string
 type resolver func(string) string
 
-func (e _endpoint) validationHandler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if entries, err := e.validateRequest(r); err != nil {
-			api.RequestErrorHandler(w, r, err.Error())
-		} else {
-			ctx := context.WithValue(r.Context(), "entries", entries)
-			next.ServeHTTP(w, r.WithContext(ctx))
+// maxBody bounds the size of the request bodies validated against a schema
+const maxBody = 1 << 20
+
+// types lists the parameter types understood by validate
+var types = []string{
+	"array", "boolean", "integer", "number", "string", "object",
+	"uuid", "email", "uri", "date", "date-time", "duration",
+}
+
+var (
+	numbers = []string{"enum", "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"}
+	texts   = []string{"enum", "minLength", "maxLength", "pattern"}
+)
+
+// vocabulary lists the properties honoured by validate for each type
+var vocabulary = map[string][]string{
+	"array":     {"enum", "items"},
+	"boolean":   {},
+	"integer":   numbers,
+	"number":    numbers,
+	"string":    texts,
+	"object":    {"schema"},
+	"uuid":      texts,
+	"email":     texts,
+	"uri":       texts,
+	"date":      texts,
+	"date-time": texts,
+	"duration":  texts,
+}
+
+// decimal matches numbers written as JSON writes them, leaving out
+// the forms strconv also reads such as 1_000, 0x1p4, NaN and Inf
+var decimal = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// finite parses a decimal number, refusing those out of the float64 range
+func finite(v string) (float64, bool) {
+	if !decimal.MatchString(v) {
+		return 0, false
+	}
+
+	num, err := strconv.ParseFloat(v, 64)
+	return num, err == nil && !math.IsNaN(num) && !math.IsInf(num, 0)
+}
+
+// duration matches ISO 8601 durations such as P1DT12H or PT0.5S
+var duration = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+
+// unknownProperties lists the properties that validate would not honour
+// for the type, arrays also accepting the properties of their items
+func unknownProperties(typ string, props map[string]string) []string {
+	var allowed = vocabulary[typ]
+	var unknown []string
+
+	if typ == "array" {
+		allowed = append(slices.Clone(allowed), vocabulary[props["items"]]...)
+	}
+
+	for name := range props {
+		if !slices.Contains(allowed, name) {
+			unknown = append(unknown, name)
 		}
-	})
+	}
+
+	slices.Sort(unknown)
+	return unknown
 }
 
-func (e _endpoint) validateRequest(r *http.Request) (entries, error) {
-	var method = registry.methods[e.id][r.Method]
-	var entries = make(entries)
+// violation is a validation failure naming the rule that was broken
+type violation struct {
+	parameter string
+	value     string
+	rule      string
+	message   string
+}
+
+func (v violation) Error() string {
+	return v.message
+}
+
+func violated(rule, value, message string) error {
+	return violation{value: value, rule: rule, message: message}
+}
+
+// qualify prefixes every violation within err with the path of its value
+func qualify(path string, err error) error {
+	var qualified []error
+	for _, v := range flatten(err) {
+		if v.parameter == "" {
+			v.parameter = path
+			v.message = path + ": " + v.message
+		}
+		qualified = append(qualified, v)
+	}
+	return errors.Join(qualified...)
+}
 
-	var withPrefix = func(prefix string, err error) error {
-		return errors.New(prefix + ": " + err.Error())
+// flatten unwraps the violations joined within err
+func flatten(err error) []violation {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var violations []violation
+		for _, err := range joined.Unwrap() {
+			violations = append(violations, flatten(err)...)
+		}
+		return violations
+	}
+
+	if v, ok := err.(violation); ok {
+		return []violation{v}
+	} else if err != nil {
+		return []violation{{message: err.Error()}}
 	}
+	return nil
+}
+
+// validationHandler validates the requests to an endpoint against
+// the registry the route tree serving them was built from
+func (registry _registry) validationHandler(e _endpoint) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entries, violations, err := registry.validateRequest(e, r)
+
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				message := "request body exceeds %v bytes"
+				message = util.Message(message, tooLarge.Limit)
+				api.TooLargeErrorHandler(w, r, message)
+			case len(violations) != 0:
+				api.ValidationErrorHandler(w, r, violations)
+			default:
+				next.ServeHTTP(w, values.With(r, entries))
+			}
+		})
+	}
+}
+
+// validateRequest validates every section of the request
+// so that all violations are reported in a single response,
+// an error being returned when the body could not be read
+func (registry _registry) validateRequest(e _endpoint, r *http.Request) (values.Entries, []api.Violation, error) {
+	var method = registry.methods[e.Methods][r.Method]
+	var entries = make(values.Entries)
+	var violations []api.Violation
 
 	var uriResolver = func(s string) string { return chi.URLParam(r, s) }
-	if params, err := validateParameters(uriResolver, e.uriParams); err != nil {
-		return nil, withPrefix("uri parameters", err)
-	} else {
-		entries["uri"] = params
+	var sections = []struct {
+		location string
+		get      resolver
+		params   int
+	}{
+		{"uri", uriResolver, e.UriParams},
+		{"headers", r.Header.Get, method.Headers},
+		{"query", r.URL.Query().Get, method.Query},
 	}
 
-	if params, err := validateParameters(r.Header.Get, method.headers); err != nil {
-		return nil, withPrefix("headers", err)
-	} else {
-		entries["headers"] = params
+	for _, section := range sections {
+		params, issues := registry.validateParameters(section.location, section.get, section.params)
+		entries[section.location] = params
+		violations = append(violations, issues...)
 	}
 
-	if params, err := validateParameters(r.URL.Query().Get, method.query); err != nil {
-		return nil, withPrefix("query parameters", err)
-	} else {
-		entries["query"] = params
+	if method.Body != 0 && slices.Contains([]string{"POST", "PUT", "PATCH"}, r.Method) {
+		body, err := registry.validateBody(r, method.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return entries, violations, err
+		}
+
+		entries["body"] = body
+		violations = append(violations, report("body", "$", err)...)
 	}
 
-	return entries, nil
+	return entries, violations, nil
 }
 
-func validateParameters(get resolver, params int) (map[string]string, error) {
-	if params == 0 {
-		return nil, nil
+// report converts the violations within err to their api representation
+func report(location, parameter string, err error) []api.Violation {
+	var violations []api.Violation
+	for _, v := range flatten(err) {
+		if v.parameter == "" {
+			v.parameter = parameter
+		}
+		violations = append(violations, api.Violation{
+			Location:  location,
+			Parameter: v.parameter,
+			Value:     v.value,
+			Rule:      v.rule,
+			Message:   v.message,
+		})
 	}
+	return violations
+}
 
-	var entries = make(map[string]string)
-	var missing []string
-	var issues []string
+// validateBody decodes the JSON request body against the schema of a
+// parameter group and restores it so that it may be read by the handler
+func (registry _registry) validateBody(r *http.Request, schema int) (map[string]any, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, err
+	} else if err != nil {
+		return nil, violated("json", "", "failed to read the request body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
 
-	for name, p := range registry.parameters[params] {
-		if v := get(name); v == "" {
-			if p.required {
-				missing = append(missing, name)
+	var document any = map[string]any{}
+	if len(bytes.TrimSpace(body)) != 0 {
+		if document, err = decode(body); err != nil {
+			return nil, violated("json", "", "malformed JSON: "+err.Error())
+		}
+	}
+
+	return registry.validateObject("$", document, schema)
+}
+
+// decode parses a JSON document keeping numbers in their textual form
+func decode(data []byte) (any, error) {
+	var document any
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&document)
+
+	return document, err
+}
+
+// validateObject checks every parameter of the schema group against the
+// members of a JSON object, qualifying issues with the path of each value
+func (registry _registry) validateObject(path string, value any, schema int) (map[string]any, error) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, qualify(path, violated("type", "", "must be an object"))
+	}
+
+	var params = registry.parameters[schema]
+	var typed = make(map[string]any)
+	var issues []error
+
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		p := params[name]
+		if v, ok := object[name]; !ok || v == nil {
+			if p.Required {
+				issues = append(issues, qualify(path+"."+name, violated("required", "", "must be provided")))
 			}
+		} else if v, err := registry.validateValue(path+"."+name, p, v); err != nil {
+			issues = append(issues, err)
+		} else {
+			typed[name] = v
+		}
+	}
+
+	return typed, errors.Join(issues...)
+}
+
+// validateValue checks a single JSON value, descending into objects and
+// arrays and handing scalars to validate in their textual form
+func (registry _registry) validateValue(path string, p _parameter, value any) (any, error) {
+	var props = registry.properties[p.Properties]
+
+	var mismatch = func(kind string) (any, error) {
+		return nil, qualify(path, violated("type", "", "must be "+kind))
+	}
+
+	switch p.Type {
+	case "object":
+		schema, _ := strconv.Atoi(props["schema"])
+		return registry.validateObject(path, value, schema)
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch("an array")
+		}
+
+		if items, ok := props["items"]; ok && items != "array" {
+			p.Type = items
 		} else {
-			if err := validate(p, v); err != nil {
-				issues = append(issues, err.Error())
+			return nil, qualify(path, violated("items", "", "array property 'items' not defined"))
+		}
+
+		var typed = make([]any, len(items))
+		var issues []error
+		for i, v := range items {
+			item := util.Message("%s[%v]", path, i)
+			if v, err := registry.validateValue(item, p, v); err != nil {
+				issues = append(issues, err)
 			} else {
-				entries[name] = v
+				typed[i] = v
 			}
 		}
+		return typed, errors.Join(issues...)
 	}
 
-	if len(missing) != 0 {
-		missing := strings.Join(missing, ", ")
-		return nil, errors.New(missing + " must be provided")
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		if p.Type != "integer" && p.Type != "number" {
+			return mismatch("of type " + p.Type)
+		}
+		text = v.String()
+	case bool:
+		if p.Type != "boolean" {
+			return mismatch("of type " + p.Type)
+		}
+		text = strconv.FormatBool(v)
+	case string:
+		if slices.Contains([]string{"integer", "number", "boolean"}, p.Type) {
+			return mismatch("of type " + p.Type)
+		}
+		text = v
+	default:
+		return mismatch("of type " + p.Type)
 	}
 
-	if len(issues) != 0 {
-		issues := strings.Join(issues, ". ")
-		return nil, errors.New(issues)
+	if v, err := registry.validate(p, text); err != nil {
+		return nil, qualify(path, err)
+	} else {
+		return v, nil
 	}
+}
 
-	return entries, nil
+func (registry _registry) validateParameters(location string, get resolver, params int) (map[string]any, []api.Violation) {
+	if params == 0 {
+		return nil, nil
+	}
+
+	var entries = make(map[string]any)
+	var group = registry.parameters[params]
+	var violations []api.Violation
+
+	for _, name := range slices.Sorted(maps.Keys(group)) {
+		p := group[name]
+		if v := get(name); v == "" {
+			if p.Required {
+				err := violated("required", "", name+" must be provided")
+				violations = append(violations, report(location, name, err)...)
+			}
+		} else if v, err := registry.validate(p, v); err != nil {
+			violations = append(violations, report(location, name, err)...)
+		} else {
+			entries[name] = v
+		}
+	}
+
+	return entries, violations
 }
 
-func validate(p _parameter, v string) error {
-	var props = registry.properties[p.properties]
+// validate checks a textual value against the type and properties
+// of the parameter, returning the value converted to its type
+func (registry _registry) validate(p _parameter, v string) (any, error) {
+	var props = registry.properties[p.Properties]
 
-	var conversion = func(s string) error {
+	var conversion = func(s string) (any, error) {
 		message := "failed to convert (%s:%s) to %s"
-		message = util.Message(message, p.name, v, s)
-		return errors.New(message)
+		message = util.Message(message, p.Name, v, s)
+		return nil, violated("type", v, message)
 	}
 
-	if enum, ok := props["enum"]; ok {
+	if enum, ok := props["enum"]; ok && p.Type != "array" {
 		values := strings.Split(enum, ",")
 		if !slices.Contains(values, v) {
 			message := "%s (%s) must be in (%s)"
-			message = util.Message(message, p.name, v, enum)
-			return errors.New(message)
+			message = util.Message(message, p.Name, v, enum)
+			return nil, violated("enum", v, message)
 		}
 	}
 
-	switch p.typ {
+	switch p.Type {
 
 	case "array":
-		if items, ok := props["items"]; ok {
-			p.typ = items
+		if items, ok := props["items"]; ok && items != "array" {
+			p.Type = items
 		} else {
 			message := "array property 'items' not defined for %s"
-			message = util.Message(message, p.name)
-			return errors.New(message)
+			message = util.Message(message, p.Name)
+			return nil, violated("items", v, message)
 		}
 
-		var issues []string
-		values := strings.Split(v, ",")
-		for _, v := range values {
-			if err := validate(p, v); err != nil {
-				issues = append(issues, err.Error())
+		var items = strings.Split(v, ",")
+		var typed = make([]any, len(items))
+		var issues []error
+		for i, v := range items {
+			if v, err := registry.validate(p, v); err != nil {
+				issues = append(issues, err)
+			} else {
+				typed[i] = v
 			}
 		}
-
-		if len(issues) != 0 {
-			message := strings.Join(issues, ". ")
-			return errors.New(message)
-		}
+		return typed, errors.Join(issues...)
 
 	case "integer":
-		if num, err := strconv.Atoi(v); err != nil {
+		if num, err := strconv.ParseInt(v, 10, 64); err != nil {
 			return conversion("int")
 		} else {
-			return numeric(props, num)
+			return num, numeric(p, props, v, float64(num))
+		}
+
+	case "number":
+		if num, ok := finite(v); !ok {
+			return conversion("float")
+		} else {
+			return num, numeric(p, props, v, num)
 		}
 
 	case "boolean":
-		if _, err := strconv.ParseBool(v); err != nil {
+		if b, err := strconv.ParseBool(v); err != nil {
 			return conversion("bool")
+		} else {
+			return b, nil
+		}
+
+	case "object":
+		document, err := decode([]byte(v))
+		if err != nil {
+			return conversion("object")
+		}
+
+		schema, _ := strconv.Atoi(props["schema"])
+		return registry.validateObject(p.Name, document, schema)
+
+	case "uuid":
+		if id, err := uuid.Parse(v); err != nil {
+			return conversion("uuid")
+		} else {
+			return id, text(p, props, v)
+		}
+
+	case "email":
+		if address, err := mail.ParseAddress(v); err != nil || address.Address != v {
+			return conversion("email")
+		}
+
+	case "uri":
+		if uri, err := url.Parse(v); err != nil || !uri.IsAbs() {
+			return conversion("uri")
+		}
+
+	case "date":
+		if date, err := time.Parse(time.DateOnly, v); err != nil {
+			return conversion("date")
+		} else {
+			return date, text(p, props, v)
+		}
+
+	case "date-time":
+		if moment, err := time.Parse(time.RFC3339, v); err != nil {
+			return conversion("date-time")
+		} else {
+			return moment, text(p, props, v)
+		}
+
+	case "duration":
+		if !duration.MatchString(v) || strings.HasSuffix(v, "T") || v == "P" {
+			return conversion("duration")
+		}
+	}
+
+	return v, text(p, props, v)
+}
+
+// lengths lists the string constraints checked by text as integers
+var lengths = []string{"minLength", "maxLength"}
+
+func text(p _parameter, props map[string]string, v string) error {
+	var length = utf8.RuneCountInString(v)
+
+	if limit, ok := props["minLength"]; ok {
+		if bound, err := strconv.Atoi(limit); err == nil && length < bound {
+			message := "%s (%s) must be at least %s characters long"
+			message = util.Message(message, p.Name, v, limit)
+			return violated("minLength", v, message)
+		}
+	}
+
+	if limit, ok := props["maxLength"]; ok {
+		if bound, err := strconv.Atoi(limit); err == nil && length > bound {
+			message := "%s (%s) must be at most %s characters long"
+			message = util.Message(message, p.Name, v, limit)
+			return violated("maxLength", v, message)
+		}
+	}
+
+	if pattern, ok := props["pattern"]; ok {
+		if matched, err := regexp.MatchString(pattern, v); err == nil && !matched {
+			message := "%s (%s) must match %s"
+			message = util.Message(message, p.Name, v, pattern)
+			return violated("pattern", v, message)
 		}
 	}
 
 	return nil
 }
 
-func numeric(props map[string]string, number int) error {
+// bounds lists the numeric constraints in the order numeric checks them
+var bounds = []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"}
+
+func numeric(p _parameter, props map[string]string, v string, number float64) error {
+	for _, name := range bounds {
+		limit, ok := props[name]
+		if !ok {
+			continue
+		}
+
+		// comparisons with NaN all being false, such values fail every bound
+		bound, ok := finite(limit)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			message := "%s (%s) cannot be compared with %s %s"
+			message = util.Message(message, p.Name, v, name, limit)
+			return violated(name, v, message)
+		}
+
+		var rule string
+		switch name {
+		case "minimum":
+			if number < bound {
+				rule = "at least"
+			}
+		case "maximum":
+			if number > bound {
+				rule = "at most"
+			}
+		case "exclusiveMinimum":
+			if number <= bound {
+				rule = "greater than"
+			}
+		case "exclusiveMaximum":
+			if number >= bound {
+				rule = "less than"
+			}
+		case "multipleOf":
+			quotient := number / bound
+			if bound != 0 && math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+				rule = "a multiple of"
+			}
+		}
+
+		if rule != "" {
+			message := "%s (%s) must be %s %s"
+			message = util.Message(message, p.Name, v, rule, limit)
+			return violated(name, v, message)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,206 @@
+package system
+
+import (
+	"slices"
+	"testing"
+)
+
+// check is a case of validate: a value of a parameter of the given type,
+// constrained by props, and whether it passes
+type check struct {
+	typ   string
+	props map[string]string
+	value string
+	valid bool
+}
+
+// run validates the value of every case with a registry holding its props
+func run(t *testing.T, cases []check) {
+	t.Helper()
+
+	for _, c := range cases {
+		var registry = blank()
+		registry.properties[1] = c.props
+
+		p := _parameter{Name: "x", Type: c.typ, Properties: 1}
+		if _, err := registry.validate(p, c.value); (err == nil) != c.valid {
+			t.Errorf("%s %q with %v: expected valid to be %v, got %v", c.typ, c.value, c.props, c.valid, err)
+		}
+	}
+}
+
+func TestValidateNumeric(t *testing.T) {
+	var maximum = map[string]string{"maximum": "10"}
+	var minimum = map[string]string{"minimum": "-1.5"}
+	var exclusive = map[string]string{"exclusiveMinimum": "0", "exclusiveMaximum": "1"}
+	var multiple = map[string]string{"multipleOf": "3"}
+
+	var cases = []check{
+		{"number", nil, "1.5", true},
+		{"number", nil, "-2e3", true},
+		{"number", maximum, "10", true},
+		{"number", maximum, "11", false},
+		{"number", maximum, "NaN", false},
+		{"number", maximum, "Inf", false},
+		{"number", maximum, "-Inf", false},
+		{"number", nil, "+Inf", false},
+		{"number", nil, "infinity", false},
+		{"number", nil, "1_0", false},
+		{"number", nil, "0x1p4", false},
+		{"number", nil, "1e400", false},
+		{"number", nil, ".5", false},
+		{"number", nil, "01", false},
+		{"integer", maximum, "10", true},
+		{"integer", maximum, "11", false},
+		{"integer", nil, "1_0", false},
+		{"integer", nil, "0x10", false},
+		{"integer", nil, "1.0", false},
+		{"integer", map[string]string{"minimum": "NaN"}, "1", false},
+		{"integer", map[string]string{"exclusiveMaximum": "Inf"}, "1", false},
+		{"number", map[string]string{"multipleOf": "NaN"}, "1", false},
+		{"number", map[string]string{"multipleOf": "0.5"}, "1.5", true},
+		{"number", map[string]string{"exclusiveMinimum": "1"}, "1", false},
+		{"number", minimum, "-1.5", true},
+		{"number", minimum, "-1.6", false},
+		{"integer", minimum, "-1", true},
+		{"integer", minimum, "-2", false},
+		{"number", exclusive, "0.5", true},
+		{"number", exclusive, "0", false},
+		{"number", exclusive, "1", false},
+		{"integer", multiple, "9", true},
+		{"integer", multiple, "-6", true},
+		{"integer", multiple, "10", false},
+		{"number", map[string]string{"multipleOf": "0.1"}, "0.3", true},
+		{"integer", map[string]string{"enum": "1,2,3"}, "2", true},
+		{"integer", map[string]string{"enum": "1,2,3"}, "4", false},
+		{"integer", map[string]string{"maximum": "5", "multipleOf": "2"}, "6", false},
+	}
+
+	run(t, cases)
+}
+
+func TestPropertyRules(t *testing.T) {
+	var cases = []struct {
+		typ   string
+		props map[string]string
+		valid bool
+	}{
+		{"integer", map[string]string{"minimum": "-1.5e2"}, true},
+		{"integer", map[string]string{"minimum": "NaN"}, false},
+		{"number", map[string]string{"maximum": "Inf"}, false},
+		{"number", map[string]string{"multipleOf": "0x1p4"}, false},
+		{"number", map[string]string{"exclusiveMaximum": "1e400"}, false},
+		{"string", map[string]string{"minLength": "-1"}, false},
+		{"string", map[string]string{"pattern": "("}, false},
+		{"array", map[string]string{"items": "array"}, false},
+		{"array", map[string]string{"items": "integer", "maximum": "5"}, true},
+		{"boolean", map[string]string{"maximum": "5"}, false},
+	}
+
+	for _, c := range cases {
+		if err := propertyRules(c.typ, c.props); (err == nil) != c.valid {
+			t.Errorf("%s with %v: expected valid to be %v, got %v", c.typ, c.props, c.valid, err)
+		}
+	}
+}
+
+func TestValidateText(t *testing.T) {
+	var lengths = map[string]string{"minLength": "2", "maxLength": "3"}
+
+	var cases = []check{
+		{"string", lengths, "ab", true},
+		{"string", lengths, "ééé", true},
+		{"string", lengths, "a", false},
+		{"string", lengths, "abcd", false},
+		{"string", map[string]string{"pattern": "^[a-z]+$"}, "abc", true},
+		{"string", map[string]string{"pattern": "^[a-z]+$"}, "ab1", false},
+		{"string", map[string]string{"enum": "red,green"}, "green", true},
+		{"string", map[string]string{"enum": "red,green"}, "blue", false},
+		{"boolean", nil, "true", true},
+		{"boolean", nil, "yes", false},
+		{"array", map[string]string{"items": "integer"}, "1,2", true},
+		{"array", map[string]string{"items": "integer"}, "1,x", false},
+		{"array", map[string]string{"items": "integer", "maximum": "5"}, "1,6", false},
+		{"array", nil, "1,2", false},
+	}
+
+	run(t, cases)
+}
+
+func TestValidateFormats(t *testing.T) {
+	var cases = []check{
+		{"uuid", nil, "0b3c1a4e-8f6d-4b7a-9c2e-5d1f0a9b8c7d", true},
+		{"uuid", nil, "0b3c1a4e", false},
+		{"uuid", map[string]string{"pattern": "^0"}, "1b3c1a4e-8f6d-4b7a-9c2e-5d1f0a9b8c7d", false},
+		{"email", nil, "ada@example.com", true},
+		{"email", nil, "Ada <ada@example.com>", false},
+		{"email", nil, "ada", false},
+		{"uri", nil, "https://example.com/a?b=c", true},
+		{"uri", nil, "/relative", false},
+		{"date", nil, "2024-02-29", true},
+		{"date", nil, "2023-02-29", false},
+		{"date", nil, "2024-1-1", false},
+		{"date-time", nil, "2024-01-01T10:00:00+02:00", true},
+		{"date-time", nil, "2024-01-01 10:00:00", false},
+		{"duration", nil, "P1DT12H", true},
+		{"duration", nil, "PT0.5S", true},
+		{"duration", nil, "P2W", true},
+		{"duration", nil, "P", false},
+		{"duration", nil, "P1DT", false},
+		{"duration", nil, "1D", false},
+	}
+
+	run(t, cases)
+}
+
+func TestValidateBody(t *testing.T) {
+	var registry = blank()
+	registry.properties[1] = map[string]string{"maximum": "150"}
+	registry.properties[2] = map[string]string{"items": "string", "maxLength": "3"}
+	registry.properties[3] = map[string]string{"schema": "2"}
+	registry.parameters[1] = map[string]_parameter{
+		"name":    {Id: 1, Name: "name", Type: "string", Required: true},
+		"age":     {Id: 1, Name: "age", Type: "integer", Properties: 1},
+		"tags":    {Id: 1, Name: "tags", Type: "array", Properties: 2},
+		"address": {Id: 1, Name: "address", Type: "object", Properties: 3},
+	}
+	registry.parameters[2] = map[string]_parameter{
+		"city": {Id: 2, Name: "city", Type: "string", Required: true},
+	}
+
+	var cases = []struct {
+		body       string
+		violations []string
+	}{
+		{`{"name": "ada", "age": 36, "tags": ["a", "b"], "address": {"city": "x"}}`, nil},
+		{`{"name": "ada", "age": null}`, nil},
+		{`{}`, []string{"$.name"}},
+		{`{"name": 1}`, []string{"$.name"}},
+		{`{"name": "ada", "age": "36"}`, []string{"$.age"}},
+		{`{"name": "ada", "age": 151}`, []string{"$.age"}},
+		{`{"name": "ada", "age": 1.5}`, []string{"$.age"}},
+		{`{"name": "ada", "tags": "a"}`, []string{"$.tags"}},
+		{`{"name": "ada", "tags": ["a", "long", 1]}`, []string{"$.tags[1]", "$.tags[2]"}},
+		{`{"name": "ada", "address": {}}`, []string{"$.address.city"}},
+		{`{"name": "ada", "address": []}`, []string{"$.address"}},
+		{`{"age": 200, "address": {"city": true}}`, []string{"$.address.city", "$.age", "$.name"}},
+		{`[]`, []string{"$"}},
+	}
+
+	for _, c := range cases {
+		document, err := decode([]byte(c.body))
+		if err != nil {
+			t.Fatalf("%s: %v", c.body, err)
+		}
+
+		_, err = registry.validateObject("$", document, 1)
+
+		var got []string
+		for _, v := range flatten(err) {
+			got = append(got, v.parameter)
+		}
+		if !slices.Equal(got, c.violations) {
+			t.Errorf("%s: expected violations of %v, got %v", c.body, c.violations, got)
+		}
+	}
+}
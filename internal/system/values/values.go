@@ -0,0 +1,84 @@
+package values
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entries holds the typed values produced by validation, keyed by
+// section (uri, headers, query, body) and then by parameter name
+type Entries map[string]map[string]any
+
+func From(r *http.Request) Entries {
+	entries, _ := r.Context().Value("entries").(Entries)
+	return entries
+}
+
+func With(r *http.Request, entries Entries) *http.Request {
+	ctx := context.WithValue(r.Context(), "entries", entries)
+	return r.WithContext(ctx)
+}
+
+func (e Entries) Get(section, name string) (any, bool) {
+	value, ok := e[section][name]
+	return value, ok
+}
+
+func (e Entries) Int(section, name string) (int64, bool) {
+	return Value[int64](e, section, name)
+}
+
+// Float also accepts integers so that number parameters
+// may be read uniformly regardless of their representation
+func (e Entries) Float(section, name string) (float64, bool) {
+	if number, ok := Value[int64](e, section, name); ok {
+		return float64(number), true
+	}
+	return Value[float64](e, section, name)
+}
+
+func (e Entries) Bool(section, name string) (bool, bool) {
+	return Value[bool](e, section, name)
+}
+
+func (e Entries) String(section, name string) (string, bool) {
+	return Value[string](e, section, name)
+}
+
+func (e Entries) Time(section, name string) (time.Time, bool) {
+	return Value[time.Time](e, section, name)
+}
+
+func (e Entries) UUID(section, name string) (uuid.UUID, bool) {
+	return Value[uuid.UUID](e, section, name)
+}
+
+func (e Entries) Object(section, name string) (map[string]any, bool) {
+	return Value[map[string]any](e, section, name)
+}
+
+// Value returns the entry when it was validated as a T
+func Value[T any](e Entries, section, name string) (T, bool) {
+	value, ok := e[section][name].(T)
+	return value, ok
+}
+
+// Slice returns the items of an array entry when all of them are a T
+func Slice[T any](e Entries, section, name string) ([]T, bool) {
+	items, ok := e[section][name].([]any)
+	if !ok {
+		return nil, false
+	}
+
+	var values = make([]T, len(items))
+	for i, item := range items {
+		if values[i], ok = item.(T); !ok {
+			return nil, false
+		}
+	}
+
+	return values, true
+}
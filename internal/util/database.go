@@ -2,73 +2,237 @@ package util
 
 import (
 	"context"
+	"errors"
 	"os"
 	"reflect"
-	"unsafe"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// database wraps the connection pool, Ctx being the context of work done outside of
+// requests and Timeout the deadline given to every query, none when zero
 type database struct {
-	Ctx  context.Context
-	Conn *pgxpool.Pool
+	Ctx     context.Context
+	Conn    *pgxpool.Pool
+	Timeout time.Duration
+	cancel  context.CancelFunc
 }
 
 var Database database
 
+// defaultTimeout bounds queries when DATABASE_TIMEOUT is not set
+const defaultTimeout = 10 * time.Second
+
 func InitializeDatabase() {
-	ctx := context.Background()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	timeout := defaultTimeout
+	if value := os.Getenv("DATABASE_TIMEOUT"); value != "" {
+		var err error
+		if timeout, err = time.ParseDuration(value); err != nil || timeout < 0 {
+			panic("DATABASE_TIMEOUT must be a non-negative duration such as 5s")
+		}
+	}
+
 	db := os.Getenv("DATABASE_URL")
 	if db, err := pgxpool.New(ctx, db); err != nil {
 		panic("failed to connect to the database")
 	} else {
-		Database = database{ctx, db}
+		Database = database{ctx, db, timeout, cancel}
 	}
 }
 
-func scanner(structure any) []any {
-	var pointers []any
+// deadline derives the context of a single query from the caller's
+func (db *database) deadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.Timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.Timeout)
+}
+
+// rows releases the deadline of a query once its rows are closed
+type rows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+}
+
+func (r rows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+func (r rows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.cancel()
+	return false
+}
 
-	value := reflect.ValueOf(structure)
-	pointer := unsafe.Pointer(value.Pointer())
-	fields := reflect.TypeOf(value.Elem().Interface())
+// scanner maps the columns of a result onto the fields of the structure
+// pointed to by scan, matching each column with the db tag of a field or,
+// without one, its name; pgx converts the values, so fields may be of any
+// type it scans into: pointers and pgtype values for nullable columns,
+// time.Time, []byte, uuid.UUID or a decoded type for JSON columns
+func scanner(scan any, columns []pgconn.FieldDescription) ([]any, error) {
+	value := reflect.ValueOf(scan)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return []any{scan}, nil
+	}
+
+	structure := value.Elem()
+	fields := make(map[string]int)
+	for i := 0; i < structure.NumField(); i++ {
+		field := structure.Type().Field(i)
+
+		name, ok := field.Tag.Lookup("db")
+		if name == "-" {
+			continue
+		}
+		if !ok {
+			name = field.Name
+		}
 
-	if value.Elem().Kind() != reflect.Struct {
-		return []any{structure}
+		if !field.IsExported() {
+			if ok {
+				message := "field %s of %s must be exported to be scanned"
+				return nil, errors.New(Message(message, field.Name, structure.Type()))
+			}
+			continue
+		}
+		fields[strings.ToLower(name)] = i
 	}
 
-	for i := 0; i < fields.NumField(); i++ {
-		field := fields.Field(i)
-		offset := unsafe.Add(pointer, field.Offset)
-
-		switch field.Type.String() {
-		case "int":
-			pointers = append(pointers, (*int)(offset))
-		case "string":
-			pointers = append(pointers, (*string)(offset))
-		case "bool":
-			pointers = append(pointers, (*bool)(offset))
+	var pointers []any
+	for _, column := range columns {
+		i, ok := fields[strings.ToLower(column.Name)]
+		if !ok {
+			message := "column %s has no destination field in %s"
+			return nil, errors.New(Message(message, column.Name, structure.Type()))
 		}
+		pointers = append(pointers, structure.Field(i).Addr().Interface())
 	}
 
-	return pointers
+	return pointers, nil
 }
 
-func (db *database) Query(sql string, args ...any) (pgx.Rows, error) {
-	return db.Conn.Query(db.Ctx, sql, args...)
+func (db *database) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, cancel := db.deadline(ctx)
+	result, err := db.Conn.Query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return rows{result, cancel}, nil
 }
 
 func (*database) ForEach(rows pgx.Rows, scan any, method func() error) error {
-	_, err := pgx.ForEachRow(rows, scanner(scan), method)
+	pointers, err := scanner(scan, rows.FieldDescriptions())
+	if err != nil {
+		rows.Close()
+		return err
+	}
+
+	_, err = pgx.ForEachRow(rows, pointers, method)
 	return err
 }
 
-func (db *database) QueryRow(row any, sql string, args ...any) error {
-	return db.Conn.QueryRow(db.Ctx, sql, args...).Scan(row)
+func (db *database) Exec(ctx context.Context, sql string, args ...any) error {
+	ctx, cancel := db.deadline(ctx)
+	defer cancel()
+
+	_, err := db.Conn.Exec(ctx, sql, args...)
+	return err
+}
+
+func (db *database) QueryRow(ctx context.Context, row any, sql string, args ...any) error {
+	ctx, cancel := db.deadline(ctx)
+	defer cancel()
+
+	return db.Conn.QueryRow(ctx, sql, args...).Scan(row)
+}
+
+// Tx is a transaction offering the same helpers as the database
+type Tx struct {
+	tx  pgx.Tx
+	ctx context.Context
+}
+
+func (tx Tx) Exec(sql string, args ...any) error {
+	_, err := tx.tx.Exec(tx.ctx, sql, args...)
+	return err
+}
+
+func (tx Tx) QueryRow(row any, sql string, args ...any) error {
+	return tx.tx.QueryRow(tx.ctx, sql, args...).Scan(row)
+}
+
+func (tx Tx) Query(sql string, args ...any) (pgx.Rows, error) {
+	return tx.tx.Query(tx.ctx, sql, args...)
+}
+
+// Transaction runs method within a transaction which is committed when it
+// returns nil and rolled back when it returns an error or panics, the
+// deadline of a single query applying to the transaction as a whole
+func (db *database) Transaction(ctx context.Context, method func(tx Tx) error) error {
+	return db.begin(ctx, pgx.TxOptions{}, method)
+}
+
+// Snapshot runs method within a read only transaction at repeatable read,
+// so that all of its queries see the database as it was at the first one
+func (db *database) Snapshot(ctx context.Context, method func(tx Tx) error) error {
+	return db.begin(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, method)
+}
+
+func (db *database) begin(ctx context.Context, options pgx.TxOptions, method func(tx Tx) error) error {
+	ctx, cancel := db.deadline(ctx)
+	defer cancel()
+
+	tx, err := db.Conn.BeginTx(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if err := method(Tx{tx, ctx}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Listen hands the payload of every notification sent on channel to notified,
+// calling listening once they are awaited, until the context is done or the
+// connection fails; the connection is closed rather than returned to the pool
+func (db *database) Listen(ctx context.Context, channel string, listening func(), notified func(payload string)) error {
+	pooled, err := db.Conn.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn := pooled.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	listening()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		notified(notification.Payload)
+	}
 }
 
+// Close cancels the work still running outside of requests and closes the pool
 func (db *database) Close() {
-	db.Ctx.Done()
+	db.cancel()
 	db.Conn.Close()
 }
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type scanned struct {
+	Id      int    `db:"id"`
+	Name    string `db:"Name"`
+	Plain   string
+	Skipped string `db:"-"`
+}
+
+type unexported struct {
+	id int `db:"id"`
+}
+
+func TestScanner(t *testing.T) {
+	var cases = []struct {
+		name    string
+		columns []string
+		want    []string
+		err     string
+	}{
+		{"tags", []string{"id", "name"}, []string{"Id", "Name"}, ""},
+		{"case", []string{"ID", "NAME", "plain"}, []string{"Id", "Name", "Plain"}, ""},
+		{"order", []string{"plain", "id"}, []string{"Plain", "Id"}, ""},
+		{"skipped", []string{"skipped"}, nil, "column skipped has no destination field"},
+		{"missing", []string{"id", "other"}, nil, "column other has no destination field"},
+	}
+
+	for _, c := range cases {
+		var row scanned
+		var columns []pgconn.FieldDescription
+		for _, name := range c.columns {
+			columns = append(columns, pgconn.FieldDescription{Name: name})
+		}
+
+		pointers, err := scanner(&row, columns)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected %q, got %v", c.name, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		fields := map[string]any{"Id": &row.Id, "Name": &row.Name, "Plain": &row.Plain}
+		if len(pointers) != len(c.want) {
+			t.Fatalf("%s: expected %v pointers, got %v", c.name, len(c.want), len(pointers))
+		}
+		for i, field := range c.want {
+			if pointers[i] != fields[field] {
+				t.Errorf("%s: expected column %s to be scanned into %s", c.name, c.columns[i], field)
+			}
+		}
+	}
+}
+
+func TestScannerValues(t *testing.T) {
+	var count int
+	pointers, err := scanner(&count, []pgconn.FieldDescription{{Name: "count"}})
+	if err != nil || len(pointers) != 1 || pointers[0] != &count {
+		t.Errorf("expected a value other than a structure to be scanned as is, got %v %v", pointers, err)
+	}
+
+	_, err = scanner(&unexported{}, []pgconn.FieldDescription{{Name: "id"}})
+	if err == nil || !strings.Contains(err.Error(), "must be exported") {
+		t.Errorf("expected tagged unexported fields to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package util
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationFile matches the file names of the embedded migrations,
+// as in 0001_catalog.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lock serializes migrations between instances sharing the database
+const lock = 7244203
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// Migration reports whether a migration has been applied and when
+type Migration struct {
+	Version int        `json:"version"`
+	Name    string     `json:"name"`
+	Applied *time.Time `json:"applied,omitempty"`
+}
+
+func available() ([]migration, error) {
+	var found = make(map[int]*migration)
+
+	files, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		match := migrationFile.FindStringSubmatch(file.Name())
+		if match == nil {
+			return nil, errors.New(Message("migration %s is not named VERSION_NAME.(up|down).sql", file.Name()))
+		}
+
+		sql, err := migrations.ReadFile(path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		if found[version] == nil {
+			found[version] = &migration{version: version, name: match[2]}
+		}
+		if match[3] == "up" {
+			found[version].up = string(sql)
+		} else {
+			found[version].down = string(sql)
+		}
+	}
+
+	var sorted []migration
+	for _, m := range found {
+		sorted = append(sorted, *m)
+	}
+	slices.SortFunc(sorted, func(a, b migration) int { return a.version - b.version })
+
+	return sorted, nil
+}
+
+// applied lists the versions recorded in schema_migrations
+func (db *database) applied() (map[int]time.Time, error) {
+	sql := `CREATE TABLE IF NOT EXISTS schema_migrations (
+				version integer PRIMARY KEY,
+				name varchar NOT NULL,
+				applied timestamptz NOT NULL DEFAULT now()
+			)`
+	if err := db.Exec(db.Ctx, sql); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(db.Ctx, `SELECT version, applied FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions = make(map[int]time.Time)
+	var version int
+	var at time.Time
+	_, err = pgx.ForEachRow(rows, []any{&version, &at}, func() error {
+		versions[version] = at
+		return nil
+	})
+
+	return versions, err
+}
+
+// step runs a single migration in its own transaction, holding
+// the advisory lock so that concurrent instances wait their turn
+func (db *database) step(m migration, up bool) error {
+	// schema changes may well take longer than any query is allowed to
+	var unbounded = *db
+	unbounded.Timeout = 0
+
+	return unbounded.Transaction(db.Ctx, func(tx Tx) error {
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, lock); err != nil {
+			return err
+		}
+
+		var done bool
+		sql := `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`
+		if err := tx.QueryRow(&done, sql, m.version); err != nil {
+			return err
+		}
+
+		// another instance got there first
+		if done == up {
+			return nil
+		}
+
+		script, record := m.up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+		if !up {
+			script, record = m.down, `DELETE FROM schema_migrations WHERE version = $1 AND name = $2`
+		}
+
+		if err := tx.Exec(script); err != nil {
+			return errors.New(Message("migration %04d_%s failed: %s", m.version, m.name, err.Error()))
+		}
+		return tx.Exec(record, m.version, m.name)
+	})
+}
+
+// MigrateUp applies every pending migration in version order
+func (db *database) MigrateUp() error {
+	all, err := available()
+	if err != nil {
+		return err
+	}
+
+	versions, err := db.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		if _, ok := versions[m.version]; ok {
+			continue
+		}
+
+		if err := db.step(m, true); err != nil {
+			return err
+		}
+		logrus.WithField("migration", m.version).Info("applied migration ", m.name)
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the latest applied migrations, at most steps of them
+func (db *database) MigrateDown(steps int) error {
+	all, err := available()
+	if err != nil {
+		return err
+	}
+
+	versions, err := db.applied()
+	if err != nil {
+		return err
+	}
+
+	slices.Reverse(all)
+	for _, m := range all {
+		if steps == 0 {
+			break
+		}
+		if _, ok := versions[m.version]; !ok {
+			continue
+		}
+
+		if err := db.step(m, false); err != nil {
+			return err
+		}
+		logrus.WithField("migration", m.version).Info("reverted migration ", m.name)
+		steps--
+	}
+
+	return nil
+}
+
+// MigrationStatus lists the embedded migrations alongside when they were applied
+func (db *database) MigrationStatus() ([]Migration, error) {
+	all, err := available()
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := db.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var status []Migration
+	for _, m := range all {
+		entry := Migration{Version: m.version, Name: m.name}
+		if at, ok := versions[m.version]; ok {
+			entry.Applied = &at
+		}
+		status = append(status, entry)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS property;
+DROP TABLE IF EXISTS parameter;
+DROP TABLE IF EXISTS method;
+DROP TABLE IF EXISTS endpoint;
+
+DROP TYPE IF EXISTS type;
+DROP TYPE IF EXISTS methods;
@@ -0,0 +1,41 @@
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'methods') THEN
+        CREATE TYPE methods AS ENUM ('GET', 'POST', 'PUT', 'DELETE', 'PATCH');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'type') THEN
+        CREATE TYPE type AS ENUM ('array', 'integer', 'number', 'boolean', 'string');
+    END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS endpoint (
+    id serial PRIMARY KEY,
+    path varchar NOT NULL UNIQUE,
+    "uriParams" integer NOT NULL DEFAULT 0,
+    methods integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS method (
+    id serial,
+    name methods NOT NULL,
+    query integer NOT NULL DEFAULT 0,
+    headers integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (id, name)
+);
+
+CREATE TABLE IF NOT EXISTS parameter (
+    id serial,
+    name varchar NOT NULL,
+    type type NOT NULL,
+    required bool NOT NULL DEFAULT false,
+    properties integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (id, name)
+);
+
+CREATE TABLE IF NOT EXISTS property (
+    id serial,
+    name varchar NOT NULL,
+    value varchar NOT NULL,
+    PRIMARY KEY (id, name)
+);
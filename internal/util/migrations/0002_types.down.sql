@@ -0,0 +1,3 @@
+-- enum values cannot be dropped, parameters are moved back to string instead
+UPDATE parameter SET type = 'string'
+    WHERE type::text IN ('object', 'uuid', 'email', 'uri', 'date', 'date-time', 'duration');
@@ -0,0 +1,7 @@
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'object';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'uuid';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'email';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'uri';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'date';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'date-time';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'duration';
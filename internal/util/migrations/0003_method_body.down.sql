@@ -0,0 +1 @@
+ALTER TABLE method DROP COLUMN IF EXISTS body;
@@ -0,0 +1 @@
+ALTER TABLE method ADD COLUMN IF NOT EXISTS body integer NOT NULL DEFAULT 0;
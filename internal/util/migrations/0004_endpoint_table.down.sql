@@ -0,0 +1 @@
+ALTER TABLE endpoint DROP COLUMN IF EXISTS "table";
@@ -0,0 +1 @@
+ALTER TABLE endpoint ADD COLUMN IF NOT EXISTS "table" varchar NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE method DROP COLUMN IF EXISTS examples;
+
+DROP TABLE IF EXISTS example;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS example (
+    id serial,
+    name varchar NOT NULL,
+    status integer NOT NULL DEFAULT 0,
+    headers varchar NOT NULL DEFAULT '{}',
+    body varchar NOT NULL DEFAULT '',
+    PRIMARY KEY (id, name)
+);
+
+ALTER TABLE method ADD COLUMN IF NOT EXISTS examples integer NOT NULL DEFAULT 0;
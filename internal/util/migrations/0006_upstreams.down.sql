@@ -0,0 +1,3 @@
+ALTER TABLE endpoint DROP COLUMN IF EXISTS upstream;
+
+DROP TABLE IF EXISTS upstream;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS upstream (
+    id serial PRIMARY KEY,
+    target varchar NOT NULL,
+    timeout integer NOT NULL DEFAULT 0,
+    passthrough varchar NOT NULL DEFAULT '',
+    inject varchar NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE endpoint ADD COLUMN IF NOT EXISTS upstream integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- enum values cannot be dropped and 0001 creates these ones on new databases,
+-- so they are kept rather than moving array and number parameters elsewhere
@@ -0,0 +1,4 @@
+-- databases created before 0001 listed the types of their parameters
+-- without the array and number types the catalog relies on
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'array';
+ALTER TYPE type ADD VALUE IF NOT EXISTS 'number';
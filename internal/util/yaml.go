@@ -0,0 +1,142 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML renders any JSON serialisable value as a block style YAML document
+func YAML(value any) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var document any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(node(document)); err != nil {
+		return nil, err
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// node converts a decoded JSON value into a YAML node, numbers keeping
+// their textual form and mapping keys being written in order
+func node(value any) *yaml.Node {
+	switch v := value.(type) {
+	case map[string]any:
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			mapping.Content = append(mapping.Content, node(key), node(v[key]))
+		}
+		return mapping
+	case []any:
+		sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			sequence.Content = append(sequence.Content, node(item))
+		}
+		return sequence
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: v.String()}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// ParseYAML decodes a YAML document into the values encoding/json would
+// produce with UseNumber, so that it may be handled as a JSON document
+func ParseYAML(data []byte) (any, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	untime(&root)
+
+	var document any
+	if err := root.Decode(&document); err != nil {
+		return nil, err
+	}
+	return jsonValue(document)
+}
+
+// untime keeps timestamps as the strings they are written as,
+// JSON having no representation of its own for them
+func untime(n *yaml.Node) {
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!timestamp" {
+		n.Tag = "!!str"
+	}
+	for _, child := range n.Content {
+		untime(child)
+	}
+}
+
+// jsonValue converts a decoded YAML value into its JSON equivalent,
+// mapping keys being written as strings
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = converted
+		}
+		return object, nil
+	case map[any]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			object[fmt.Sprint(key)] = converted
+		}
+		return object, nil
+	case []any:
+		array := make([]any, len(v))
+		for i, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = converted
+		}
+		return array, nil
+	case int, int64, uint64:
+		return json.Number(fmt.Sprint(v)), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errors.New("yaml: " + fmt.Sprint(v) + " cannot be represented in JSON")
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	}
+	return value, nil
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	var cases = []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"scalars", "a: 1\nb: 2.50\nc: true\nd: null\ne: text", `{"a":1,"b":2.5,"c":true,"d":null,"e":"text"}`},
+		{"timestamps", "a: 2024-01-01", `{"a":"2024-01-01"}`},
+		{"aliases", "a: &x {k: v}\nb: *x", `{"a":{"k":"v"},"b":{"k":"v"}}`},
+		{"nested sequences", "- - a\n  - b", `[["a","b"]]`},
+		{"multi-line flow", "a: [1,\n  2]", `{"a":[1,2]}`},
+		{"escaped keys", `"k\"q": 1`, `{"k\"q":1}`},
+		{"keys", "1: a\ntrue: b", `{"1":"a","true":"b"}`},
+	}
+
+	for _, c := range cases {
+		value, err := ParseYAML([]byte(c.document))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		if got, _ := json.Marshal(value); string(got) != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+
+	if _, err := ParseYAML([]byte("a: .nan")); err == nil {
+		t.Error("expected NaN to be refused")
+	}
+}
+
+func TestYAML(t *testing.T) {
+	var value = map[string]any{
+		"number": json.Number("12345678901234567890"),
+		"quoted": []any{"true", "3", "{id}", "x: y"},
+		"text":   "multi\nline",
+		"empty":  map[string]any{},
+	}
+
+	document, err := YAML(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseYAML(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := json.Marshal(value)
+	if got, _ := json.Marshal(parsed); string(got) != string(want) {
+		t.Errorf("expected %s to read back as %s, got %s", document, want, got)
+	}
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	f "fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"Factory/internal/middleware"
 	"Factory/internal/system"
@@ -13,13 +21,35 @@ import (
 )
 
 func main() {
-	util.InitializeDatabase()
-	defer util.Database.Close()
+	// the catalog lives in the database unless STORAGE
+	// selects memory or json, the latter saved to STORAGE_FILE
+	var storage = os.Getenv("STORAGE")
+	var ctx = context.Background()
+
+	if storage == "" || storage == "postgres" {
+		util.InitializeDatabase()
+		defer util.Database.Close()
+		ctx = util.Database.Ctx
+
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			migrate(os.Args[2:])
+			return
+		}
+
+		if err := util.Database.MigrateUp(); err != nil {
+			panic("failed to migrate the database: " + err.Error())
+		}
+	}
 
 	var factory = chi.NewRouter()
 	factory.Use(chimiddle.StripSlashes)
 	factory.Use(middleware.Correlation)
-	system.Initialize(factory)
+	system.Initialize(factory, storage, os.Getenv("STORAGE_FILE"))
+
+	// replicas sharing the database pick up the catalog changes of one another
+	if util.Database.Conn != nil {
+		go system.Synchronize(ctx, resync())
+	}
 
 	f.Println("Starting the ...")
 	f.Print(`
@@ -31,7 +61,94 @@ func main() {
        @@@@@@@@		   @@@@@@@@@@		 	@@			   @@@@@@@@@@@	@@@@@@@@@@@	  	 @@			@@@@@@@@@@     @@       @@  	@@
 `)
 
-	if err := http.ListenAndServe("localhost:8080", factory); err != nil {
+	// requests derive from the database context, if any, so that closing
+	// the database on shutdown cancels the queries still running
+	var server = http.Server{
+		Addr:        "localhost:8080",
+		Handler:     factory,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-signals.Done()
+
+		f.Println("Stopping the factory ...")
+		ctx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			f.Println("Requests still running were cancelled:", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		f.Println("Failed to start the factory !!")
+		return
+	}
+	<-stopped
+}
+
+// grace is how long running requests may take to finish on shutdown
+const grace = 15 * time.Second
+
+// resync is how often the whole catalog is reloaded from the database, as set
+// by SYNC_INTERVAL, in case a change notified by another replica was missed
+func resync() time.Duration {
+	var interval = time.Minute
+	if value := os.Getenv("SYNC_INTERVAL"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil || interval < 0 {
+			panic("SYNC_INTERVAL must be a non-negative duration such as 30s")
+		}
+	}
+	return interval
+}
+
+// migrate runs the migrate command: up, down [steps] or status
+func migrate(args []string) {
+	var command = "status"
+	if len(args) != 0 {
+		command = args[0]
+	}
+
+	var err error
+	switch command {
+	case "up":
+		err = util.Database.MigrateUp()
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				f.Println("steps must be a positive integer")
+				os.Exit(2)
+			}
+		}
+		err = util.Database.MigrateDown(steps)
+
+	case "status":
+		var status []util.Migration
+		if status, err = util.Database.MigrationStatus(); err == nil {
+			for _, m := range status {
+				applied := "pending"
+				if m.Applied != nil {
+					applied = "applied " + m.Applied.Format("2006-01-02 15:04:05")
+				}
+				f.Printf("%04d  %-20s %s\n", m.Version, m.Name, applied)
+			}
+		}
+
+	default:
+		f.Println("usage: Factory migrate [up | down [steps] | status]")
+		os.Exit(2)
+	}
+
+	if err != nil {
+		f.Println("Failed to migrate the database:", err)
+		os.Exit(1)
 	}
 }